@@ -0,0 +1,334 @@
+package td
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+type AccountActivityType string
+
+const (
+	ActivitySubscribed                AccountActivityType = "SUBSCRIBED"
+	ActivityError                     AccountActivityType = "ERROR"
+	ActivityBrokenTrade               AccountActivityType = "BrokenTrade"
+	ActivityManualExecution           AccountActivityType = "ManualExecution"
+	ActivityOrderActivation           AccountActivityType = "OrderActivation"
+	ActivityOrderCancelReplaceRequest AccountActivityType = "OrderCancelReplaceRequest"
+	ActivityOrderCancelRequest        AccountActivityType = "OrderCancelRequest"
+	ActivityOrderEntryRequest         AccountActivityType = "OrderEntryRequest"
+	ActivityOrderFill                 AccountActivityType = "OrderFill"
+	ActivityOrderPartialFill          AccountActivityType = "OrderPartialFill"
+	ActivityOrderRejection            AccountActivityType = "OrderRejection"
+	ActivityTooLateToCancel           AccountActivityType = "TooLateToCancel"
+	ActivityUROUT                     AccountActivityType = "UROUT"
+)
+
+// ActivityTime is a timestamp in the account activity xml messages.
+type ActivityTime string
+
+func (at ActivityTime) Time() (t time.Time) {
+	t, _ = time.Parse(time.RFC3339Nano, strings.TrimSpace(string(at)))
+	return
+}
+
+// AccountEvent is implemented by all the account activity events.
+type AccountEvent interface {
+	Activity() *AccountActivityEvent
+}
+
+// AccountActivityEvent is the part shared by all the account activity messages,
+// it is also returned as is for SUBSCRIBED, ERROR and unknown message types.
+type AccountActivityEvent struct {
+	Key       string              `xml:"-"`
+	AccountID string              `xml:"-"`
+	Type      AccountActivityType `xml:"-"`
+
+	// Data is the raw message data, usually xml.
+	Data string `xml:"-"`
+
+	OrderGroupID      *OrderGroupID  `xml:"OrderGroupID"`
+	ActivityTimestamp ActivityTime   `xml:"ActivityTimestamp"`
+	Order             *ActivityOrder `xml:"Order"`
+}
+
+func (e *AccountActivityEvent) Activity() *AccountActivityEvent { return e }
+
+// OrderID returns the order key of the event's order or 0 if there isn't one.
+func (e *AccountActivityEvent) OrderID() int64 {
+	if e.Order == nil {
+		return 0
+	}
+	return e.Order.OrderKey
+}
+
+type OrderGroupID struct {
+	Firm           string `xml:"Firm"`
+	Branch         string `xml:"Branch"`
+	ClientKey      string `xml:"ClientKey"`
+	AccountKey     string `xml:"AccountKey"`
+	Segment        string `xml:"Segment"`
+	SubAccountType string `xml:"SubAccountType"`
+	CDDomainID     string `xml:"CDDomainID"`
+}
+
+type ActivitySecurity struct {
+	CUSIP            string `xml:"CUSIP"`
+	Symbol           string `xml:"Symbol"`
+	SecurityType     string `xml:"SecurityType"`
+	SecurityCategory string `xml:"SecurityCategory"`
+	ShortDescription string `xml:"ShortDescription"`
+	SymbolUnderlying string `xml:"SymbolUnderlying"`
+}
+
+type ActivityOrderPricing struct {
+	Limit float64 `xml:"Limit"`
+	Stop  float64 `xml:"Stop"`
+	Bid   float64 `xml:"Bid"`
+	Ask   float64 `xml:"Ask"`
+}
+
+// ActivityOrderLeg is a leg of a multi-leg order.
+type ActivityOrderLeg struct {
+	LegID             int64             `xml:"LegID"`
+	Security          *ActivitySecurity `xml:"Security"`
+	OrderInstructions string            `xml:"OrderInstructions"`
+	Quantity          float64           `xml:"Quantity"`
+	OpenClose         string            `xml:"OpenClose"`
+}
+
+type ActivityOrder struct {
+	OrderKey             int64                 `xml:"OrderKey"`
+	Security             *ActivitySecurity     `xml:"Security"`
+	OrderPricing         *ActivityOrderPricing `xml:"OrderPricing"`
+	OrderType            string                `xml:"OrderType"`
+	OrderDuration        string                `xml:"OrderDuration"`
+	OrderEnteredDateTime ActivityTime          `xml:"OrderEnteredDateTime"`
+	OrderInstructions    string                `xml:"OrderInstructions"`
+	OriginalQuantity     float64               `xml:"OriginalQuantity"`
+	AmountIndicator      string                `xml:"AmountIndicator"`
+	Discretionary        bool                  `xml:"Discretionary"`
+	OrderSource          string                `xml:"OrderSource"`
+	Solicited            bool                  `xml:"Solicited"`
+	MarketCode           string                `xml:"MarketCode"`
+	Capacity             string                `xml:"Capacity"`
+	EnteringDevice       string                `xml:"EnteringDevice"`
+	OpenClose            string                `xml:"OpenClose"`
+	ComplexOrderType     string                `xml:"ComplexOrderType"`
+	Legs                 []*ActivityOrderLeg   `xml:"OrderLegs>OrderLeg"`
+}
+
+// Symbol returns the order's symbol, or the symbol of the first leg for multi-leg orders.
+func (o *ActivityOrder) Symbol() string {
+	if o.Security != nil {
+		return o.Security.Symbol
+	}
+	if len(o.Legs) > 0 && o.Legs[0].Security != nil {
+		return o.Legs[0].Security.Symbol
+	}
+	return ""
+}
+
+type ExecutionInformation struct {
+	Type                  string       `xml:"Type"`
+	Timestamp             ActivityTime `xml:"Timestamp"`
+	Quantity              float64      `xml:"Quantity"`
+	ExecutionPrice        float64      `xml:"ExecutionPrice"`
+	AveragePriceIndicator bool         `xml:"AveragePriceIndicator"`
+	LeavesQuantity        float64      `xml:"LeavesQuantity"`
+	ID                    string       `xml:"ID"`
+	Exchange              string       `xml:"Exchange"`
+	BrokerID              string       `xml:"BrokerId"`
+	LegID                 int64        `xml:"LegId"`
+}
+
+type OrderEntryRequestEvent struct {
+	AccountActivityEvent
+	LastUpdated  ActivityTime `xml:"LastUpdated"`
+	ConfirmTexts []string     `xml:"ConfirmTexts>ConfirmText"`
+}
+
+type OrderActivationEvent struct {
+	AccountActivityEvent
+	ActivatedOrderKey int64 `xml:"ActivatedOrderKey"`
+}
+
+type OrderFillEvent struct {
+	AccountActivityEvent
+	OrderCompletionCode string                  `xml:"OrderCompletionCode"`
+	Executions          []*ExecutionInformation `xml:"ExecutionInformation"`
+	TradeDate           string                  `xml:"TradeDate"`
+}
+
+// Quantity returns the total filled quantity of all the executions.
+func (e *OrderFillEvent) Quantity() (qty float64) {
+	for _, ex := range e.Executions {
+		qty += ex.Quantity
+	}
+	return
+}
+
+// Price returns the average execution price weighted by quantity.
+func (e *OrderFillEvent) Price() float64 {
+	var total, qty float64
+	for _, ex := range e.Executions {
+		total += ex.ExecutionPrice * ex.Quantity
+		qty += ex.Quantity
+	}
+	if qty == 0 {
+		return 0
+	}
+	return total / qty
+}
+
+type OrderPartialFillEvent struct {
+	OrderFillEvent
+	RemainingQuantity float64 `xml:"RemainingQuantity"`
+}
+
+type OrderCancelRequestEvent struct {
+	AccountActivityEvent
+	LastUpdated           ActivityTime `xml:"LastUpdated"`
+	PendingCancelQuantity float64      `xml:"PendingCancelQuantity"`
+}
+
+type OrderCancelReplaceRequestEvent struct {
+	AccountActivityEvent
+	PendingCancelQuantity float64 `xml:"PendingCancelQuantity"`
+	OriginalOrderID       int64   `xml:"OriginalOrderId"`
+}
+
+type OrderRejectionEvent struct {
+	AccountActivityEvent
+	LastUpdated  ActivityTime `xml:"LastUpdated"`
+	RejectCode   string       `xml:"RejectCode"`
+	RejectReason string       `xml:"RejectReason"`
+	ReportedBy   string       `xml:"ReportedBy"`
+}
+
+type TooLateToCancelEvent struct {
+	AccountActivityEvent
+	LastUpdated ActivityTime `xml:"LastUpdated"`
+}
+
+type UROUTEvent struct {
+	AccountActivityEvent
+	CancelledQuantity float64 `xml:"CancelledQuantity"`
+	OrderDestination  string  `xml:"OrderDestination"`
+	InternalExternal  string  `xml:"InternalExternal"`
+}
+
+type BrokenTradeEvent struct {
+	AccountActivityEvent
+	ErrorDescription string `xml:"ErrorDescription"`
+}
+
+type ManualExecutionEvent struct {
+	AccountActivityEvent
+	Executions []*ExecutionInformation `xml:"ExecutionInformation"`
+}
+
+type rawAccountActivity struct {
+	Key       string `json:"key"`
+	AccountID string `json:"1"`
+	Type      string `json:"2"`
+	Data      string `json:"3"`
+}
+
+// ParseAccountActivity decodes a raw ACCT_ACTIVITY message into one of the *Event types,
+// if the xml data can't be parsed it returns an *AccountActivityEvent with the raw Data and the error.
+func ParseAccountActivity(v Any) (AccountEvent, error) {
+	var raw rawAccountActivity
+	if err := json.Unmarshal(marshalAny(v), &raw); err != nil {
+		return nil, err
+	}
+
+	base := AccountActivityEvent{Key: raw.Key, AccountID: raw.AccountID, Type: AccountActivityType(raw.Type), Data: raw.Data}
+
+	var ev AccountEvent
+	switch base.Type {
+	case ActivityOrderEntryRequest:
+		ev = &OrderEntryRequestEvent{AccountActivityEvent: base}
+	case ActivityOrderActivation:
+		ev = &OrderActivationEvent{AccountActivityEvent: base}
+	case ActivityOrderFill:
+		ev = &OrderFillEvent{AccountActivityEvent: base}
+	case ActivityOrderPartialFill:
+		ev = &OrderPartialFillEvent{OrderFillEvent: OrderFillEvent{AccountActivityEvent: base}}
+	case ActivityOrderCancelRequest:
+		ev = &OrderCancelRequestEvent{AccountActivityEvent: base}
+	case ActivityOrderCancelReplaceRequest:
+		ev = &OrderCancelReplaceRequestEvent{AccountActivityEvent: base}
+	case ActivityOrderRejection:
+		ev = &OrderRejectionEvent{AccountActivityEvent: base}
+	case ActivityTooLateToCancel:
+		ev = &TooLateToCancelEvent{AccountActivityEvent: base}
+	case ActivityUROUT:
+		ev = &UROUTEvent{AccountActivityEvent: base}
+	case ActivityBrokenTrade:
+		ev = &BrokenTradeEvent{AccountActivityEvent: base}
+	case ActivityManualExecution:
+		ev = &ManualExecutionEvent{AccountActivityEvent: base}
+	default:
+		return &base, nil
+	}
+
+	if err := xml.Unmarshal([]byte(raw.Data), ev); err != nil {
+		return &base, err
+	}
+
+	return ev, nil
+}
+
+// AccountActivitySubscription delivers typed account activity events.
+type AccountActivitySubscription struct {
+	*Subscription
+
+	// C shadows Subscription.C.
+	C <-chan AccountEvent
+}
+
+// AccountActivity subscribes to the order activity of all the accounts covered by the subscription keys,
+// if accountIDs are given, only their events are delivered.
+// Messages that fail to parse are delivered as an *AccountActivityEvent with only the raw Data set.
+func (s *Streamer) AccountActivity(ctx context.Context, accountIDs ...string) (*AccountActivitySubscription, error) {
+	const svc = "ACCT_ACTIVITY"
+	const fields = "0,1,2,3"
+
+	keys := s.SubscriptionKeys()
+	if len(keys) == 0 {
+		return nil, ErrNoSubscriptionKeys
+	}
+
+	var only map[string]bool
+	if len(accountIDs) > 0 {
+		only = make(map[string]bool, len(accountIDs))
+		for _, id := range accountIDs {
+			only[id] = true
+		}
+	}
+
+	sub, err := s.Subscribe(ctx, svc, StreamRequestParams{Keys: strings.Join(keys, ","), Fields: fields})
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan AccountEvent, cap(sub.ch))
+	sub.forward(func(v Any) {
+		ev, _ := ParseAccountActivity(v)
+		if ev == nil {
+			return
+		}
+		if only != nil && !only[ev.Activity().AccountID] {
+			return
+		}
+		select {
+		case ch <- ev:
+		case <-sub.cancel:
+		}
+	}, func() { close(ch) })
+
+	return &AccountActivitySubscription{Subscription: sub, C: ch}, nil
+}
@@ -0,0 +1,139 @@
+package td
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+const orderFillXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<OrderFillMessage xmlns="urn:xmlns:beb.ameritrade.com">
+	<OrderGroupID>
+		<Firm>150</Firm>
+		<Branch>123</Branch>
+		<ClientKey>123456789</ClientKey>
+		<AccountKey>123456789</AccountKey>
+		<Segment>ngoms</Segment>
+		<SubAccountType>Margin</SubAccountType>
+		<CDDomainID>A000000012345678</CDDomainID>
+	</OrderGroupID>
+	<ActivityTimestamp>2020-08-14T09:30:01.123-05:00</ActivityTimestamp>
+	<Order>
+		<OrderKey>1234567890</OrderKey>
+		<Security>
+			<CUSIP>037833100</CUSIP>
+			<Symbol>AAPL</Symbol>
+			<SecurityType>Common Stock</SecurityType>
+		</Security>
+		<OrderPricing>
+			<Limit>460.5</Limit>
+			<Bid>460.4</Bid>
+			<Ask>460.6</Ask>
+		</OrderPricing>
+		<OrderType>Limit</OrderType>
+		<OrderDuration>Day</OrderDuration>
+		<OrderEnteredDateTime>2020-08-14T09:30:00.5-05:00</OrderEnteredDateTime>
+		<OrderInstructions>Buy</OrderInstructions>
+		<OriginalQuantity>10</OriginalQuantity>
+		<Discretionary>false</Discretionary>
+		<OrderSource>Web</OrderSource>
+		<Solicited>false</Solicited>
+		<MarketCode>Normal</MarketCode>
+		<Capacity>Agency</Capacity>
+		<EnteringDevice>AA_USER</EnteringDevice>
+	</Order>
+	<OrderCompletionCode>Normal Completion</OrderCompletionCode>
+	<ExecutionInformation>
+		<Type>Bought</Type>
+		<Timestamp>2020-08-14T09:30:01.1-05:00</Timestamp>
+		<Quantity>4</Quantity>
+		<ExecutionPrice>460.5</ExecutionPrice>
+		<AveragePriceIndicator>false</AveragePriceIndicator>
+		<LeavesQuantity>6</LeavesQuantity>
+		<ID>1</ID>
+		<Exchange>Q</Exchange>
+		<BrokerId>NITE</BrokerId>
+	</ExecutionInformation>
+	<ExecutionInformation>
+		<Type>Bought</Type>
+		<Timestamp>2020-08-14T09:30:01.12-05:00</Timestamp>
+		<Quantity>6</Quantity>
+		<ExecutionPrice>460.25</ExecutionPrice>
+		<AveragePriceIndicator>false</AveragePriceIndicator>
+		<LeavesQuantity>0</LeavesQuantity>
+		<ID>2</ID>
+	</ExecutionInformation>
+	<TradeDate>2020-08-14</TradeDate>
+</OrderFillMessage>`
+
+func rawActivity(t *testing.T, typ, data string) Any {
+	t.Helper()
+	b, err := json.Marshal(map[string]string{"key": "sub-key", "1": "123456789", "2": typ, "3": data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rawAny(t, string(b))
+}
+
+func TestParseOrderFill(t *testing.T) {
+	ev, err := ParseAccountActivity(rawActivity(t, "OrderFill", orderFillXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fill, ok := ev.(*OrderFillEvent)
+	if !ok {
+		t.Fatalf("expected *OrderFillEvent, got %T", ev)
+	}
+
+	a := ev.Activity()
+	if a.Key != "sub-key" || a.AccountID != "123456789" || a.Type != ActivityOrderFill || a.Data != orderFillXML {
+		t.Fatalf("unexpected event: %+v", a)
+	}
+	if a.OrderGroupID == nil || a.OrderGroupID.AccountKey != "123456789" || a.OrderGroupID.SubAccountType != "Margin" {
+		t.Fatalf("unexpected order group: %+v", a.OrderGroupID)
+	}
+	exp := time.Date(2020, 8, 14, 14, 30, 1, 123e6, time.UTC)
+	if ts := a.ActivityTimestamp.Time(); !ts.Equal(exp) {
+		t.Fatalf("expected %v, got %v", exp, ts)
+	}
+
+	o := a.Order
+	if a.OrderID() != 1234567890 || o.Symbol() != "AAPL" || o.OrderPricing.Limit != 460.5 || o.OriginalQuantity != 10 || o.OrderInstructions != "Buy" {
+		t.Fatalf("unexpected order: %+v", o)
+	}
+
+	if fill.OrderCompletionCode != "Normal Completion" || fill.TradeDate != "2020-08-14" || len(fill.Executions) != 2 {
+		t.Fatalf("unexpected fill: %+v", fill)
+	}
+	if ex := fill.Executions[0]; ex.Quantity != 4 || ex.ExecutionPrice != 460.5 || ex.LeavesQuantity != 6 || ex.BrokerID != "NITE" {
+		t.Fatalf("unexpected execution: %+v", ex)
+	}
+	if fill.Quantity() != 10 || fill.Price() != (4*460.5+6*460.25)/10 {
+		t.Fatalf("unexpected quantity %v or price %v", fill.Quantity(), fill.Price())
+	}
+}
+
+func TestParseAccountActivity(t *testing.T) {
+	ev, err := ParseAccountActivity(rawActivity(t, "OrderPartialFill",
+		`<OrderPartialFillMessage><Order><OrderKey>1</OrderKey></Order><RemainingQuantity>6</RemainingQuantity>`+
+			`<ExecutionInformation><Quantity>4</Quantity><ExecutionPrice>10</ExecutionPrice></ExecutionInformation></OrderPartialFillMessage>`))
+	if pf, ok := ev.(*OrderPartialFillEvent); err != nil || !ok || pf.RemainingQuantity != 6 || pf.Quantity() != 4 || pf.Activity().OrderID() != 1 {
+		t.Fatalf("unexpected partial fill: %T %+v %v", ev, ev, err)
+	}
+
+	ev, err = ParseAccountActivity(rawActivity(t, "OrderRejection",
+		`<OrderRejectionMessage><RejectCode>1</RejectCode><RejectReason>no buying power</RejectReason></OrderRejectionMessage>`))
+	if rej, ok := ev.(*OrderRejectionEvent); err != nil || !ok || rej.RejectReason != "no buying power" || rej.Activity().OrderID() != 0 {
+		t.Fatalf("unexpected rejection: %T %+v %v", ev, ev, err)
+	}
+
+	ev, err = ParseAccountActivity(rawActivity(t, "SUBSCRIBED", ""))
+	if base, ok := ev.(*AccountActivityEvent); err != nil || !ok || base.Type != ActivitySubscribed {
+		t.Fatalf("unexpected event: %T %+v %v", ev, ev, err)
+	}
+
+	ev, err = ParseAccountActivity(rawActivity(t, "OrderFill", "<OrderFillMessage><Order>"))
+	if base, ok := ev.(*AccountActivityEvent); err == nil || !ok || base.Data != "<OrderFillMessage><Order>" {
+		t.Fatalf("expected the raw event and an error, got %T %+v %v", ev, ev, err)
+	}
+}
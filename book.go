@@ -0,0 +1,254 @@
+package td
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type BookService string
+
+const (
+	NasdaqBook  BookService = "NASDAQ_BOOK"
+	ListedBook  BookService = "LISTED_BOOK"
+	OptionsBook BookService = "OPTIONS_BOOK"
+)
+
+// BookMarketMaker is a single market maker's quote at a price level.
+type BookMarketMaker struct {
+	ID   string `json:"0"`
+	Size int64  `json:"1"`
+	// Time is in milliseconds, as sent by TD.
+	Time int64 `json:"2"`
+}
+
+// BookLevel is the aggregated size of all the market makers at a price.
+type BookLevel struct {
+	Price           float64            `json:"0"`
+	Size            int64              `json:"1"`
+	NumMarketMakers int                `json:"2"`
+	MarketMakers    []*BookMarketMaker `json:"3"`
+}
+
+// BookUpdate is a level two message, nil Bids or Asks mean that side didn't change.
+type BookUpdate struct {
+	Symbol   string       `json:"key"`
+	BookTime int64        `json:"1"`
+	Bids     []*BookLevel `json:"2"`
+	Asks     []*BookLevel `json:"3"`
+}
+
+func (u *BookUpdate) Time() time.Time {
+	return time.Unix(0, u.BookTime*int64(time.Millisecond))
+}
+
+// ParseBookUpdate decodes a raw NASDAQ_BOOK, LISTED_BOOK or OPTIONS_BOOK message.
+func ParseBookUpdate(v Any) (*BookUpdate, error) {
+	var u BookUpdate
+	if err := json.Unmarshal(marshalAny(v), &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// OrderBook is the in-memory level two book of a symbol, bids are sorted by price descending and asks ascending.
+type OrderBook struct {
+	mux    sync.RWMutex
+	symbol string
+	time   time.Time
+	bids   []BookLevel
+	asks   []BookLevel
+}
+
+func NewOrderBook(symbol string) *OrderBook {
+	return &OrderBook{symbol: symbol}
+}
+
+func (b *OrderBook) Symbol() string { return b.symbol }
+
+// Time returns the time of the last update applied to the book.
+func (b *OrderBook) Time() time.Time {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	return b.time
+}
+
+// Apply updates the book with u, only the sides present in u are replaced.
+func (b *OrderBook) Apply(u *BookUpdate) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.time = u.Time()
+	if u.Bids != nil {
+		b.bids = copyLevels(u.Bids)
+		sort.Slice(b.bids, func(i, j int) bool { return b.bids[i].Price > b.bids[j].Price })
+	}
+	if u.Asks != nil {
+		b.asks = copyLevels(u.Asks)
+		sort.Slice(b.asks, func(i, j int) bool { return b.asks[i].Price < b.asks[j].Price })
+	}
+}
+
+// BestBid returns the highest bid level.
+func (b *OrderBook) BestBid() (lvl BookLevel, ok bool) {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	if ok = len(b.bids) > 0; ok {
+		lvl = b.bids[0]
+	}
+	return
+}
+
+// BestAsk returns the lowest ask level.
+func (b *OrderBook) BestAsk() (lvl BookLevel, ok bool) {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	if ok = len(b.asks) > 0; ok {
+		lvl = b.asks[0]
+	}
+	return
+}
+
+// Spread returns the difference between the best ask and the best bid, or 0 if either side is empty.
+func (b *OrderBook) Spread() float64 {
+	bid, ok1 := b.BestBid()
+	ask, ok2 := b.BestAsk()
+	if !ok1 || !ok2 {
+		return 0
+	}
+	return ask.Price - bid.Price
+}
+
+// Depth returns up to n levels of each side, n <= 0 returns the whole book.
+func (b *OrderBook) Depth(n int) (bids, asks []BookLevel) {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	return topLevels(b.bids, n), topLevels(b.asks, n)
+}
+
+// Level returns the level at the given price on the bid (bid = true) or the ask side,
+// its MarketMakers field has the per market maker breakdown.
+func (b *OrderBook) Level(bid bool, price float64) (lvl BookLevel, ok bool) {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	levels := b.asks
+	if bid {
+		levels = b.bids
+	}
+	for _, l := range levels {
+		if l.Price == price {
+			return l, true
+		}
+	}
+	return
+}
+
+// SizeAt returns the total size on one side up to and including the given price.
+func (b *OrderBook) SizeAt(bid bool, price float64) (size int64) {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	if bid {
+		for _, l := range b.bids {
+			if l.Price < price {
+				break
+			}
+			size += l.Size
+		}
+		return
+	}
+	for _, l := range b.asks {
+		if l.Price > price {
+			break
+		}
+		size += l.Size
+	}
+	return
+}
+
+func copyLevels(levels []*BookLevel) []BookLevel {
+	out := make([]BookLevel, 0, len(levels))
+	for _, l := range levels {
+		if l == nil {
+			continue
+		}
+		lvl := *l
+		lvl.MarketMakers = make([]*BookMarketMaker, len(l.MarketMakers))
+		for i, mm := range l.MarketMakers {
+			cp := *mm
+			lvl.MarketMakers[i] = &cp
+		}
+		out = append(out, lvl)
+	}
+	return out
+}
+
+func topLevels(levels []BookLevel, n int) []BookLevel {
+	if n <= 0 || n > len(levels) {
+		n = len(levels)
+	}
+	out := make([]BookLevel, n)
+	copy(out, levels[:n])
+	return out
+}
+
+// BookSubscription is a level two subscription, it keeps an OrderBook for every symbol.
+type BookSubscription struct {
+	*Subscription
+
+	// C shadows Subscription.C, reading from the raw channel will steal updates from the books.
+	// Updates are dropped if C is full, the books are always kept up to date.
+	C <-chan *BookUpdate
+
+	mux   sync.RWMutex
+	books map[string]*OrderBook
+}
+
+// OrderBook returns the book for symbol or nil if no data was received for it yet.
+func (bs *BookSubscription) OrderBook(symbol string) *OrderBook {
+	bs.mux.RLock()
+	defer bs.mux.RUnlock()
+	return bs.books[symbol]
+}
+
+func (bs *BookSubscription) apply(u *BookUpdate) {
+	bs.mux.Lock()
+	b := bs.books[u.Symbol]
+	if b == nil {
+		b = NewOrderBook(u.Symbol)
+		bs.books[u.Symbol] = b
+	}
+	bs.mux.Unlock()
+	b.Apply(u)
+}
+
+// Book subscribes to level two data for the given symbols, requires the LevelTwoQuotes authorization.
+func (s *Streamer) Book(ctx context.Context, svc BookService, symbols ...string) (*BookSubscription, error) {
+	const allFields = "0,1,2,3"
+	sub, err := s.Subscribe(ctx, string(svc), StreamRequestParams{
+		Keys:   strings.Join(symbols, ","),
+		Fields: allFields,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *BookUpdate, cap(sub.ch))
+	bs := &BookSubscription{Subscription: sub, C: ch, books: map[string]*OrderBook{}}
+	sub.forward(func(v Any) {
+		u, err := ParseBookUpdate(v)
+		if err != nil || u.Symbol == "" {
+			return
+		}
+		bs.apply(u)
+		select {
+		case ch <- u:
+		default:
+			atomic.AddUint64(&sub.dropped, 1)
+		}
+	}, func() { close(ch) })
+
+	return bs, nil
+}
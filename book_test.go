@@ -0,0 +1,85 @@
+package td
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go.oneofone.dev/anyx"
+)
+
+// rawAny decodes a raw streamer content item.
+func rawAny(t *testing.T, s string) Any {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return anyx.Value(v)
+}
+
+func TestOrderBook(t *testing.T) {
+	u, err := ParseBookUpdate(rawAny(t, `{"key":"AAPL","1":1597411800000,
+		"2":[{"0":100.1,"1":300,"2":2,"3":[{"0":"NSDQ","1":100,"2":1597411799000},{"0":"ARCA","1":200,"2":1597411799500}]},
+			{"0":100.2,"1":100,"2":1,"3":[{"0":"NSDQ","1":100,"2":1597411799000}]},
+			{"0":99.9,"1":500,"2":1,"3":[{"0":"EDGX","1":500,"2":1597411799000}]}],
+		"3":[{"0":100.4,"1":200,"2":1,"3":[]},{"0":100.3,"1":100,"2":1,"3":[]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Symbol != "AAPL" || u.Time().Unix() != 1597411800 || len(u.Bids) != 3 || len(u.Asks) != 2 {
+		t.Fatalf("unexpected update: %+v", u)
+	}
+
+	b := NewOrderBook("AAPL")
+	b.Apply(u)
+
+	if bid, ok := b.BestBid(); !ok || bid.Price != 100.2 || bid.Size != 100 {
+		t.Fatalf("unexpected best bid: %+v", bid)
+	}
+	if ask, ok := b.BestAsk(); !ok || ask.Price != 100.3 {
+		t.Fatalf("unexpected best ask: %+v", ask)
+	}
+	if s := b.Spread(); s < 0.0999 || s > 0.1001 {
+		t.Fatalf("unexpected spread: %v", s)
+	}
+	if bids, asks := b.Depth(2); len(bids) != 2 || bids[1].Price != 100.1 || len(asks) != 2 || asks[1].Price != 100.4 {
+		t.Fatalf("unexpected depth: %+v %+v", bids, asks)
+	}
+	if lvl, ok := b.Level(true, 100.1); !ok || len(lvl.MarketMakers) != 2 || lvl.MarketMakers[1].ID != "ARCA" || lvl.MarketMakers[1].Size != 200 {
+		t.Fatalf("unexpected level: %+v", lvl)
+	}
+	if _, ok := b.Level(false, 100.1); ok {
+		t.Fatal("unexpected ask level at 100.1")
+	}
+	if n := b.SizeAt(true, 100.1); n != 400 {
+		t.Fatalf("expected 400 bid size down to 100.1, got %d", n)
+	}
+	if n := b.SizeAt(false, 100.4); n != 300 {
+		t.Fatalf("expected 300 ask size up to 100.4, got %d", n)
+	}
+
+	// the book doesn't share memory with the update
+	u.Bids[1].MarketMakers[0].Size = 1
+	if lvl, _ := b.Level(true, 100.2); lvl.MarketMakers[0].Size != 100 {
+		t.Fatal("the book was modified through the update")
+	}
+
+	// only the sides present in the update are replaced
+	u, err = ParseBookUpdate(rawAny(t, `{"key":"AAPL","1":1597411801000,"3":[{"0":100.25,"1":50,"2":1}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Apply(u)
+	if bid, _ := b.BestBid(); bid.Price != 100.2 {
+		t.Fatalf("unexpected best bid after an ask update: %+v", bid)
+	}
+	if ask, _ := b.BestAsk(); ask.Price != 100.25 || b.Time().Unix() != 1597411801 {
+		t.Fatalf("unexpected best ask: %+v", ask)
+	}
+
+	u, _ = ParseBookUpdate(rawAny(t, `{"key":"AAPL","1":1597411802000,"2":[]}`))
+	b.Apply(u)
+	if _, ok := b.BestBid(); ok || b.Spread() != 0 {
+		t.Fatal("expected an empty bid side")
+	}
+}
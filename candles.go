@@ -0,0 +1,249 @@
+package td
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type rawEquityChart struct {
+	Symbol string  `json:"key"`
+	Open   float64 `json:"1"`
+	High   float64 `json:"2"`
+	Low    float64 `json:"3"`
+	Close  float64 `json:"4"`
+	Volume float64 `json:"5"`
+	Time   int64   `json:"7"`
+}
+
+type rawChart struct {
+	Symbol string  `json:"key"`
+	Time   int64   `json:"1"`
+	Open   float64 `json:"2"`
+	High   float64 `json:"3"`
+	Low    float64 `json:"4"`
+	Close  float64 `json:"5"`
+	Volume float64 `json:"6"`
+}
+
+// ParseChartCandle decodes a raw CHART_* message, CHART_EQUITY uses a different field layout than the other chart types.
+func ParseChartCandle(chartType ChartType, v Any) (symbol string, c Candle, err error) {
+	var rc rawChart
+	if chartType == EquityChart {
+		var re rawEquityChart
+		if err = json.Unmarshal(marshalAny(v), &re); err != nil {
+			return
+		}
+		rc = rawChart{Symbol: re.Symbol, Time: re.Time, Open: re.Open, High: re.High, Low: re.Low, Close: re.Close, Volume: re.Volume}
+	} else if err = json.Unmarshal(marshalAny(v), &rc); err != nil {
+		return
+	}
+
+	return rc.Symbol, Candle{
+		Open:     rc.Open,
+		High:     rc.High,
+		Low:      rc.Low,
+		Close:    rc.Close,
+		Volume:   int(rc.Volume),
+		Datetime: msDateTime(rc.Time),
+	}, nil
+}
+
+func msDateTime(ms int64) DateTime {
+	return DateTime(strconv.FormatInt(ms, 10))
+}
+
+// tradesSource is the source key used for trades in CandleBuilder, chart candles use their start time.
+const tradesSource = -1
+
+// CandleBuilder aggregates candles and trades into candles of a fixed interval, starting from historical candles
+// (usually from PriceHistory) so the result is one continuous series.
+// Intervals are aligned to midnight New York time, a candle is closed once data for a later interval arrives.
+type CandleBuilder struct {
+	mux      sync.Mutex
+	interval time.Duration
+	candles  Candles
+	cur      *Candle
+	curStart time.Time
+	sources  map[int64]candleSource
+}
+
+// candleSource is the part of the current candle that came from one source, first and last are the times of its
+// first and last data so the sources can be stitched in order.
+type candleSource struct {
+	Candle
+	key         int64
+	first, last time.Time
+}
+
+// NewCandleBuilder returns a builder for the given interval, history must be sorted and at the same or a smaller interval.
+func NewCandleBuilder(interval time.Duration, history Candles) *CandleBuilder {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	b := &CandleBuilder{interval: interval}
+	for _, c := range history {
+		b.AddCandle(c)
+	}
+	return b
+}
+
+func (b *CandleBuilder) Interval() time.Duration { return b.interval }
+
+// AddCandle adds a candle of the same or a smaller interval, adding a candle with the same start time replaces the old one.
+// Candles older than the current interval are ignored. It returns the candles closed by this update.
+func (b *CandleBuilder) AddCandle(c Candle) (closed Candles) {
+	start := c.Datetime.Time()
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.add(start, start.UnixNano()/int64(time.Millisecond), candleSource{Candle: c, first: start, last: start})
+}
+
+// AddTrade adds a trade to the current candle. It returns the candles closed by this update.
+func (b *CandleBuilder) AddTrade(t *Trade) (closed Candles) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	src := candleSource{
+		Candle: Candle{Open: t.Price, High: t.Price, Low: t.Price, Close: t.Price, Volume: int(t.Size)},
+		first:  t.Time,
+		last:   t.Time,
+	}
+	if prev, ok := b.sources[tradesSource]; ok && !b.bucket(t.Time).After(b.curStart) {
+		src.Open, src.first = prev.Open, prev.first
+		src.High = maxFloat(prev.High, src.High)
+		src.Low = minFloat(prev.Low, src.Low)
+		src.Volume += prev.Volume
+		if prev.last.After(src.last) {
+			src.last = prev.last
+		}
+	}
+	return b.add(t.Time, tradesSource, src)
+}
+
+// Candles returns all the closed candles and the current one.
+func (b *CandleBuilder) Candles() Candles {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	out := make(Candles, len(b.candles), len(b.candles)+1)
+	copy(out, b.candles)
+	if b.cur != nil {
+		out = append(out, *b.cur)
+	}
+	return out
+}
+
+// Current returns the candle that is still being built.
+func (b *CandleBuilder) Current() (c Candle, ok bool) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if b.cur == nil {
+		return
+	}
+	return *b.cur, true
+}
+
+// add replaces the key source of the current candle with src and rebuilds it, the open comes from the source
+// with the earliest data and the close from the one with the latest.
+func (b *CandleBuilder) add(t time.Time, key int64, src candleSource) (closed Candles) {
+	start := b.bucket(t)
+	switch {
+	case b.cur == nil || start.After(b.curStart):
+		if b.cur != nil {
+			b.candles = append(b.candles, *b.cur)
+			closed = append(closed, *b.cur)
+		}
+		b.curStart, b.sources = start, map[int64]candleSource{}
+	case start.Before(b.curStart):
+		return
+	}
+
+	src.key = key
+	b.sources[key] = src
+
+	srcs := make([]candleSource, 0, len(b.sources))
+	for _, src := range b.sources {
+		srcs = append(srcs, src)
+	}
+	sort.Slice(srcs, func(i, j int) bool {
+		if a, b := srcs[i].first, srcs[j].first; !a.Equal(b) {
+			return a.Before(b)
+		}
+		return srcs[i].key < srcs[j].key
+	})
+
+	cur := Candle{Datetime: msDateTime(start.UnixNano() / int64(time.Millisecond))}
+	var last time.Time
+	for i, src := range srcs {
+		if i == 0 {
+			cur.Open, cur.High, cur.Low = src.Open, src.High, src.Low
+		}
+		cur.High = maxFloat(cur.High, src.High)
+		cur.Low = minFloat(cur.Low, src.Low)
+		cur.Volume += src.Volume
+		if i == 0 || !src.last.Before(last) {
+			cur.Close, last = src.Close, src.last
+		}
+	}
+	b.cur = &cur
+	return
+}
+
+// bucket returns the start of the interval t belongs to, aligned to midnight in New York.
+func (b *CandleBuilder) bucket(t time.Time) time.Time {
+	t = t.In(nytz)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, nytz)
+	return day.Add(t.Sub(day) / b.interval * b.interval)
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// CandleSubscription feeds a chart subscription into a CandleBuilder.
+type CandleSubscription struct {
+	*Subscription
+
+	// C shadows Subscription.C, it receives the candles as they close.
+	C <-chan Candle
+
+	Builder *CandleBuilder
+}
+
+// Candles subscribes to the chart of symbol and aggregates it with b, use NewCandleBuilder with the output of
+// PriceHistory to get a continuous series.
+func (s *Streamer) Candles(ctx context.Context, chartType ChartType, symbol string, b *CandleBuilder) (*CandleSubscription, error) {
+	sub, err := s.Chart(ctx, chartType, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Candle, cap(sub.ch))
+	sub.forward(func(v Any) {
+		sym, c, err := ParseChartCandle(chartType, v)
+		if err != nil || sym != symbol {
+			return
+		}
+		for _, c := range b.AddCandle(c) {
+			select {
+			case ch <- c:
+			case <-sub.cancel:
+				return
+			}
+		}
+	}, func() { close(ch) })
+
+	return &CandleSubscription{Subscription: sub, C: ch, Builder: b}, nil
+}
@@ -0,0 +1,51 @@
+package td
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCandleBuilder(t *testing.T) {
+	at := func(min, sec int) time.Time { return time.Date(2020, 8, 14, 9, min, sec, 0, nytz) }
+	candle := func(min int, o, h, l, c float64, vol int) Candle {
+		return Candle{Open: o, High: h, Low: l, Close: c, Volume: vol, Datetime: msDateTime(at(min, 0).UnixNano() / int64(time.Millisecond))}
+	}
+
+	// 1m history, the first one belongs to the previous 5m bucket
+	b := NewCandleBuilder(5*time.Minute, Candles{
+		candle(29, 99, 99.5, 98, 99, 50),
+		candle(30, 100, 103, 99.5, 101, 100),
+		candle(31, 101, 102.5, 100.5, 102.5, 100),
+	})
+	if cs := b.Candles(); len(cs) != 2 || cs[0].Close != 99 || cs[1].Open != 100 || cs[1].Close != 102.5 || cs[1].Volume != 200 {
+		t.Fatalf("unexpected history: %+v", cs)
+	}
+
+	// the trades continue the series, the first candle stays the open and the last trade is the close
+	for _, tr := range []*Trade{
+		{Time: at(32, 10), Price: 110, Size: 10},
+		{Time: at(33, 20), Price: 112, Size: 10},
+		{Time: at(34, 30), Price: 111, Size: 10},
+	} {
+		if closed := b.AddTrade(tr); len(closed) != 0 {
+			t.Fatalf("unexpected closed candles: %+v", closed)
+		}
+	}
+
+	closed := b.AddTrade(&Trade{Time: at(35, 0), Price: 108, Size: 5})
+	exp := Candle{Open: 100, High: 112, Low: 99.5, Close: 111, Volume: 230, Datetime: msDateTime(at(30, 0).UnixNano() / int64(time.Millisecond))}
+	if len(closed) != 1 || closed[0] != exp {
+		t.Fatalf("expected %+v, got %+v", exp, closed)
+	}
+
+	// a chart candle for the current minute doesn't replace the trades' open
+	b.AddCandle(candle(36, 107, 109, 106, 107.5, 20))
+	if cur, ok := b.Current(); !ok || cur.Open != 108 || cur.Close != 107.5 || cur.High != 109 || cur.Low != 106 || cur.Volume != 25 {
+		t.Fatalf("unexpected current candle: %+v", cur)
+	}
+
+	// older data is ignored
+	if closed := b.AddCandle(candle(31, 1, 1, 1, 1, 1)); len(closed) != 0 || len(b.Candles()) != 3 {
+		t.Fatalf("unexpected candles: %+v", b.Candles())
+	}
+}
@@ -0,0 +1,103 @@
+package td
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+type FuturesFrequency string
+
+const (
+	FuturesOneMinute     = FuturesFrequency("m1")
+	FuturesFiveMinutes   = FuturesFrequency("m5")
+	FuturesTenMinutes    = FuturesFrequency("m10")
+	FuturesThirtyMinutes = FuturesFrequency("m30")
+	FuturesHourly        = FuturesFrequency("h1")
+	FuturesDaily         = FuturesFrequency("d1")
+	FuturesWeekly        = FuturesFrequency("w1")
+	FuturesMonthly       = FuturesFrequency("n1")
+)
+
+type FuturesHistoryParams struct {
+	// The candle frequency, default is m1.
+	Frequency FuturesFrequency
+
+	// The period to return, for example: d5 (5 days), w4 (4 weeks), n10 (10 months), y1 (1 year).
+	// If StartTime and EndTime are provided, period should not be provided.
+	Period string
+
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+func (p *FuturesHistoryParams) params(symbol string) map[string]string {
+	out := map[string]string{
+		"symbol":    symbol,
+		"frequency": string(FuturesOneMinute),
+	}
+	if p == nil {
+		return out
+	}
+
+	if p.Frequency != "" {
+		out["frequency"] = string(p.Frequency)
+	}
+
+	if p.Period != "" {
+		out["period"] = p.Period
+	}
+
+	if !p.StartTime.IsZero() {
+		out["START_TIME"] = strconv.FormatInt(p.StartTime.UnixNano()/int64(time.Millisecond), 10)
+	}
+
+	if !p.EndTime.IsZero() {
+		out["END_TIME"] = strconv.FormatInt(p.EndTime.UnixNano()/int64(time.Millisecond), 10)
+	}
+
+	return out
+}
+
+type rawChartHistory struct {
+	RequestID json.RawMessage `json:"0"`
+	Symbol    string          `json:"1"`
+	Count     int             `json:"2"`
+	Candles   []struct {
+		Time   int64   `json:"0"`
+		Open   float64 `json:"1"`
+		High   float64 `json:"2"`
+		Low    float64 `json:"3"`
+		Close  float64 `json:"4"`
+		Volume float64 `json:"5"`
+	} `json:"3"`
+}
+
+// FuturesHistory returns the historical candles of a futures symbol (for example /ES), which aren't available through PriceHistory.
+func (s *Streamer) FuturesHistory(ctx context.Context, symbol string, params *FuturesHistoryParams) (_ Candles, err error) {
+	const svc = "CHART_HISTORY_FUTURES"
+
+	var data Any
+	if data, err = s.request(ctx, svc, "GET", params.params(symbol), true); err != nil {
+		return
+	}
+
+	var rh rawChartHistory
+	if err = json.Unmarshal(marshalAny(data), &rh); err != nil {
+		return
+	}
+
+	out := make(Candles, 0, len(rh.Candles))
+	for _, c := range rh.Candles {
+		out = append(out, Candle{
+			Open:     c.Open,
+			High:     c.High,
+			Low:      c.Low,
+			Close:    c.Close,
+			Volume:   int(c.Volume),
+			Datetime: msDateTime(c.Time),
+		})
+	}
+	return out, nil
+}
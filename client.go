@@ -8,7 +8,8 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
-	"strings"
+	"net/url"
+	"sync"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/xerrors"
@@ -84,8 +85,14 @@ type Client struct {
 	ocfg *oauth2.Config
 	c    *http.Client
 
+	lmux    sync.Mutex
+	limiter *rateLimiter
+
 	DefaultAccountID string
 
+	// BaseURL overrides APIPath, mainly used to point the client to a test server.
+	BaseURL string
+
 	OnRawResponse func(method, url string, req, resp []byte)
 }
 
@@ -94,12 +101,22 @@ func (c *Client) Token(ctx context.Context) (*oauth2.Token, error) {
 	return ts.Token()
 }
 
+// Request sends a request to the endpoint ep and decodes the response into out,
+// waiting first for the rate limit set by SetRateLimit, if any.
 func (c *Client) Request(ctx context.Context, method, ep string, in, out interface{}) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+
 	var buf bytes.Buffer
 	if in != nil {
 		json.NewEncoder(&buf).Encode(in)
 	}
-	req, _ := http.NewRequestWithContext(ctx, method, APIPath+ep, bytes.NewReader(buf.Bytes()))
+	base := c.BaseURL
+	if base == "" {
+		base = APIPath
+	}
+	req, _ := http.NewRequestWithContext(ctx, method, base+ep, bytes.NewReader(buf.Bytes()))
 	if buf.Len() > 0 {
 		req.Header.Set("Content-Type", "application/json")
 	}
@@ -128,16 +145,25 @@ func (c *Client) Request(ctx context.Context, method, ep string, in, out interfa
 	return json.NewDecoder(bytes.NewReader(b)).Decode(out)
 }
 
-func (c *Client) Quotes(ctx context.Context, symbols ...string) (out map[string]*Quote, err error) {
+// Quotes returns the quotes of the given symbols, each quote is decoded into the type matching its asset type.
+func (c *Client) Quotes(ctx context.Context, symbols ...string) (out map[string]Quote, err error) {
 	if len(symbols) == 0 {
 		err = ErrNoSymbols
 		return
 	}
-	err = c.Request(ctx, "GET", "marketdata/quotes?symbol="+strings.Join(symbols, ","), nil, &out)
-	return
+	var qm quoteMap
+	err = c.Request(ctx, "GET", "marketdata/quotes?symbol="+quoteSymbols(symbols), nil, &qm)
+	return qm, err
 }
 
-func (c *Client) Quote(ctx context.Context, symbol string) (q *Quote, err error) {
-	err = c.Request(ctx, "GET", "marketdata/quotes/"+symbol+"/quotes", nil, &q)
+// Quote returns the quote of a single symbol, see Quotes.
+func (c *Client) Quote(ctx context.Context, symbol string) (q Quote, err error) {
+	var qm quoteMap
+	if err = c.Request(ctx, "GET", "marketdata/"+url.PathEscape(symbol)+"/quotes", nil, &qm); err != nil {
+		return
+	}
+	if q = qm[symbol]; q == nil {
+		err = xerrors.Errorf("%s: no quote returned", symbol)
+	}
 	return
 }
@@ -22,9 +22,8 @@ var (
 
 func init() {
 	log.SetFlags(log.Lshortfile)
-	err := godotenv.Load(".env")
-	if err != nil {
-		log.Fatal("Error loading .env file")
+	if err := godotenv.Load(".env"); err != nil {
+		log.Println("no .env file, skipping the live tests")
 	}
 
 	consumerID = os.Getenv("CONSUMER_ID")
@@ -32,6 +31,9 @@ func init() {
 }
 
 func Test(t *testing.T) {
+	if consumerID == "" {
+		t.Skip("CONSUMER_ID isn't set")
+	}
 	var tok *oauth2.Token
 	otk.ReadJSONFile("./.token.json", &tok)
 	c, err := NewWithAutoAuth(ctx, consumerID, "http://localhost:9000/", tok)
@@ -67,26 +69,26 @@ func Test(t *testing.T) {
 	checkAndPrint(t, nil, err)
 	defer s.Close()
 
-	ch, err := s.Subscribe(ctx, "QUOTE", StreamRequestParams{Keys: "QQQ,SPY", Fields: "0,1,2,3,4,5,6,7"})
+	sub, err := s.Subscribe(ctx, "QUOTE", StreamRequestParams{Keys: "QQQ,SPY", Fields: "0,1,2,3,4,5,6,7"})
 	checkAndPrint(t, nil, err)
-	t.Log(<-ch)
-	t.Log(<-ch)
-	t.Log(<-ch)
-	ch, err = s.Chart(ctx, EquityChart, "QQQ", "SPY")
+	t.Log(<-sub.C)
+	t.Log(<-sub.C)
+	t.Log(<-sub.C)
+	sub, err = s.Chart(ctx, EquityChart, "QQQ", "SPY")
 	checkAndPrint(t, nil, err)
 	ts := time.Now()
-	ach, _ := s.AccountActivity(ctx)
+	asub, _ := s.AccountActivity(ctx)
 	for i := 0; i < 3; i++ {
 		select {
-		case v := <-ach:
+		case v := <-asub.C:
 			t.Log(time.Since(ts), v)
-		case v := <-ch:
+		case v := <-sub.C:
 			t.Log(time.Since(ts), v.Get("7").Time("U").UTC(), v)
 		}
 		ts = time.Now()
 	}
 
-	// t.Log(s.Unsubcribe(ctx, "ACC_ACTIVITY"))
+	// t.Log(s.Unsubscribe(ctx, "ACC_ACTIVITY"))
 	// t.Log(<-ch)
 	// os, err := c.Orders(ctx, "", 0, "", "", "")
 	// checkAndPrint(t, nil, err)
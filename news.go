@@ -0,0 +1,109 @@
+package td
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+type NewsType string
+
+const (
+	NewsHeadline     NewsType = "NEWS_HEADLINE"
+	NewsHeadlineList NewsType = "NEWS_HEADLINELIST"
+)
+
+// Headline is a streaming news headline.
+type Headline struct {
+	Symbol       string    `json:"symbol,omitempty"`
+	ErrorCode    int       `json:"errorCode,omitempty"`
+	Time         time.Time `json:"time,omitempty"`
+	ID           string    `json:"id,omitempty"`
+	Status       string    `json:"status,omitempty"`
+	Headline     string    `json:"headline,omitempty"`
+	StoryID      string    `json:"storyId,omitempty"`
+	KeywordCount int       `json:"keywordCount,omitempty"`
+	Keywords     []string  `json:"keywords,omitempty"`
+	Hot          bool      `json:"hot,omitempty"`
+	Source       string    `json:"source,omitempty"`
+}
+
+type rawHeadline struct {
+	Symbol       string          `json:"key"`
+	ErrorCode    int             `json:"1"`
+	Time         int64           `json:"2"`
+	ID           string          `json:"3"`
+	Status       string          `json:"4"`
+	Headline     string          `json:"5"`
+	StoryID      string          `json:"6"`
+	KeywordCount int             `json:"7"`
+	Keywords     json.RawMessage `json:"8"`
+	Hot          bool            `json:"9"`
+	Source       string          `json:"10"`
+}
+
+// ParseHeadline decodes a raw NEWS_HEADLINE message.
+func ParseHeadline(v Any) (*Headline, error) {
+	var rh rawHeadline
+	if err := json.Unmarshal(marshalAny(v), &rh); err != nil {
+		return nil, err
+	}
+
+	h := &Headline{
+		Symbol:       rh.Symbol,
+		ErrorCode:    rh.ErrorCode,
+		Time:         time.Unix(0, rh.Time*int64(time.Millisecond)),
+		ID:           rh.ID,
+		Status:       rh.Status,
+		Headline:     rh.Headline,
+		StoryID:      rh.StoryID,
+		KeywordCount: rh.KeywordCount,
+		Hot:          rh.Hot,
+		Source:       rh.Source,
+	}
+
+	// keywords are sent either as a comma separated string or as an array
+	var kw string
+	if err := json.Unmarshal(rh.Keywords, &kw); err == nil {
+		h.Keywords = splitKeys(kw)
+	} else {
+		json.Unmarshal(rh.Keywords, &h.Keywords)
+	}
+
+	return h, nil
+}
+
+// NewsSubscription delivers typed headlines.
+type NewsSubscription struct {
+	*Subscription
+
+	// C shadows Subscription.C.
+	C <-chan *Headline
+}
+
+// News subscribes to the news headlines of the given symbols, requires the StreamingNews authorization.
+func (s *Streamer) News(ctx context.Context, newsType NewsType, symbols ...string) (*NewsSubscription, error) {
+	const allFields = "0,1,2,3,4,5,6,7,8,9,10"
+	sub, err := s.Subscribe(ctx, string(newsType), StreamRequestParams{
+		Keys:   strings.Join(symbols, ","),
+		Fields: allFields,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *Headline, cap(sub.ch))
+	sub.forward(func(v Any) {
+		h, err := ParseHeadline(v)
+		if err != nil {
+			return
+		}
+		select {
+		case ch <- h:
+		case <-sub.cancel:
+		}
+	}, func() { close(ch) })
+
+	return &NewsSubscription{Subscription: sub, C: ch}, nil
+}
@@ -0,0 +1,29 @@
+package td
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseHeadline(t *testing.T) {
+	h, err := ParseHeadline(rawAny(t, `{"key":"AAPL","1":0,"2":1597411800000,"3":"ID1","4":"C","5":"Apple beats estimates",
+		"6":"SN1","7":2,"8":"earnings, tech","9":true,"10":"DJ"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := &Headline{Symbol: "AAPL", ID: "ID1", Status: "C", Headline: "Apple beats estimates", StoryID: "SN1",
+		KeywordCount: 2, Keywords: []string{"earnings", "tech"}, Hot: true, Source: "DJ"}
+	exp.Time = h.Time
+	if h.Time.Unix() != 1597411800 || !reflect.DeepEqual(h, exp) {
+		t.Fatalf("expected %+v, got %+v", exp, h)
+	}
+
+	// keywords can also be an array
+	if h, err = ParseHeadline(rawAny(t, `{"key":"MSFT","8":["cloud","ai"]}`)); err != nil || !reflect.DeepEqual(h.Keywords, []string{"cloud", "ai"}) {
+		t.Fatalf("unexpected keywords: %v %v", h.Keywords, err)
+	}
+
+	if h, err = ParseHeadline(rawAny(t, `{"key":"MSFT","1":1}`)); err != nil || h.ErrorCode != 1 || h.Keywords != nil {
+		t.Fatalf("unexpected headline: %+v %v", h, err)
+	}
+}
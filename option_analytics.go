@@ -0,0 +1,286 @@
+package td
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const defaultMultiplier = 100
+
+// ContractMultiplier returns the number of shares per contract, 100 if TD didn't send it.
+func (o *Option) ContractMultiplier() float64 {
+	if o.Multiplier > 0 {
+		return o.Multiplier
+	}
+	return defaultMultiplier
+}
+
+// MaxPain is the settlement price of an expiration that minimizes the value of the open interest at expiration.
+type MaxPain struct {
+	Expiration time.Time
+	Strike     float64
+
+	// Payout is the total intrinsic value of the open interest if the underlying settles at Strike.
+	Payout float64
+}
+
+// MaxPain returns the max pain of every expiration with open interest, sorted by expiration.
+// Only the strikes are considered as settlement prices.
+func (oc *OptionChain) MaxPain() []MaxPain {
+	var out []MaxPain
+	for _, opts := range groupByExpiration(oc.Options()) {
+		var strikes []float64
+		var oi int
+		for _, o := range opts {
+			if n := len(strikes); n == 0 || strikes[n-1] != o.StrikePrice {
+				strikes = append(strikes, o.StrikePrice)
+			}
+			oi += o.OpenInterest
+		}
+		if oi == 0 {
+			continue
+		}
+
+		mp := MaxPain{Expiration: opts[0].Expiration(), Payout: math.Inf(1)}
+		for _, price := range strikes {
+			var payout float64
+			for _, o := range opts {
+				intrinsic := price - o.StrikePrice
+				if !o.IsCall() {
+					intrinsic = -intrinsic
+				}
+				if intrinsic > 0 {
+					payout += intrinsic * float64(o.OpenInterest) * o.ContractMultiplier()
+				}
+			}
+			if payout < mp.Payout {
+				mp.Strike, mp.Payout = price, payout
+			}
+		}
+		out = append(out, mp)
+	}
+	return out
+}
+
+// PutCallRatio holds the put and call volume and open interest, the ratios are NaN when there are no calls.
+type PutCallRatio struct {
+	// Expiration is zero for the whole chain.
+	Expiration time.Time
+
+	PutVolume        int
+	CallVolume       int
+	PutOpenInterest  int
+	CallOpenInterest int
+
+	Volume       float64
+	OpenInterest float64
+}
+
+func newPutCallRatio(exp time.Time, opts []*Option) (r PutCallRatio) {
+	r.Expiration = exp
+	for _, o := range opts {
+		if o.IsCall() {
+			r.CallVolume += o.TotalVolume
+			r.CallOpenInterest += o.OpenInterest
+		} else {
+			r.PutVolume += o.TotalVolume
+			r.PutOpenInterest += o.OpenInterest
+		}
+	}
+	r.Volume, r.OpenInterest = ratio(r.PutVolume, r.CallVolume), ratio(r.PutOpenInterest, r.CallOpenInterest)
+	return
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return math.NaN()
+	}
+	return float64(a) / float64(b)
+}
+
+// PutCallRatio returns the put/call volume and open interest ratios of the whole chain.
+func (oc *OptionChain) PutCallRatio() PutCallRatio {
+	return newPutCallRatio(time.Time{}, oc.Options())
+}
+
+// PutCallRatios returns the put/call volume and open interest ratios of every expiration, sorted by expiration.
+func (oc *OptionChain) PutCallRatios() []PutCallRatio {
+	groups := groupByExpiration(oc.Options())
+	out := make([]PutCallRatio, 0, len(groups))
+	for _, opts := range groups {
+		out = append(out, newPutCallRatio(opts[0].Expiration(), opts))
+	}
+	return out
+}
+
+// StrikeGamma is the dealer gamma exposure of a strike in dollars per 1% move of the underlying.
+type StrikeGamma struct {
+	Strike float64
+	Call   float64
+	Put    float64
+	Net    float64
+}
+
+// GammaExposure is the dealer gamma exposure of a chain, assuming dealers are long the calls and short the puts.
+type GammaExposure struct {
+	ByStrike []StrikeGamma
+	Net      float64
+
+	// ZeroGamma is the underlying price where the net exposure crosses zero, with the gamma of every contract
+	// recomputed at that price by Black-Scholes from its volatility (falling back to the chain's) and open interest.
+	// It's searched within 50% of the underlying price and the crossing closest to it is used,
+	// NaN if there's none or no option has a usable volatility.
+	ZeroGamma float64
+}
+
+// GammaExposure returns the net gamma exposure by strike of the options matching f (nil for all of them)
+// from the chain's Gamma and OpenInterest, options with invalid gammas are skipped.
+// Times to expiration are DaysToExpiration in years of 365 days, same day options count as one day.
+func (oc *OptionChain) GammaExposure(f *OptionFilter) *GammaExposure {
+	price := oc.UnderlyingPrice
+	if price <= 0 && oc.Underlying != nil {
+		price = oc.Underlying.Mark
+	}
+
+	ge := &GammaExposure{ZeroGamma: math.NaN()}
+	if price <= 0 {
+		return ge
+	}
+
+	var rate float64
+	if ValidGreek(oc.InterestRate) {
+		rate = oc.InterestRate / 100
+	}
+
+	byStrike := map[float64]*StrikeGamma{}
+	var legs []gammaLeg
+	for _, o := range oc.Filter(f) {
+		if o.OpenInterest == 0 {
+			continue
+		}
+		oi := float64(o.OpenInterest) * o.ContractMultiplier()
+		if !o.IsCall() {
+			oi = -oi
+		}
+		if l, ok := newGammaLeg(oc, o, oi); ok {
+			legs = append(legs, l)
+		}
+
+		if !ValidGreek(o.Gamma) {
+			continue
+		}
+		sg := byStrike[o.StrikePrice]
+		if sg == nil {
+			sg = &StrikeGamma{Strike: o.StrikePrice}
+			byStrike[o.StrikePrice] = sg
+		}
+		// gamma is per $1, scale it to a 1% move in dollars
+		v := o.Gamma * oi * price * price * 0.01
+		if o.IsCall() {
+			sg.Call += v
+		} else {
+			sg.Put += v
+		}
+		sg.Net = sg.Call + sg.Put
+	}
+
+	for _, sg := range byStrike {
+		ge.ByStrike = append(ge.ByStrike, *sg)
+		ge.Net += sg.Net
+	}
+	sort.Slice(ge.ByStrike, func(i, j int) bool { return ge.ByStrike[i].Strike < ge.ByStrike[j].Strike })
+
+	if len(legs) > 0 {
+		ge.ZeroGamma = zeroGamma(legs, price, rate)
+	}
+	return ge
+}
+
+// gammaLeg is an option's open interest in shares, negative for puts, with its Black-Scholes inputs.
+type gammaLeg struct {
+	shares, strike, years, vol float64
+}
+
+func newGammaLeg(oc *OptionChain, o *Option, shares float64) (l gammaLeg, ok bool) {
+	switch {
+	case ValidGreek(o.Volatility) && o.Volatility > 0:
+		l.vol = o.Volatility / 100
+	case ValidGreek(oc.Volatility) && oc.Volatility > 0:
+		l.vol = oc.Volatility / 100
+	default:
+		return l, false
+	}
+	dte := o.DaysToExpiration
+	if dte < 1 {
+		dte = 1
+	}
+	l.shares, l.strike, l.years = shares, o.StrikePrice, float64(dte)/365
+	return l, o.StrikePrice > 0
+}
+
+// netGamma returns the net exposure of legs in dollars per 1% move at the underlying price s.
+func netGamma(legs []gammaLeg, s, rate float64) (net float64) {
+	for _, l := range legs {
+		vt := l.vol * math.Sqrt(l.years)
+		d1 := (math.Log(s/l.strike) + (rate+l.vol*l.vol/2)*l.years) / vt
+		net += l.shares * math.Exp(-d1*d1/2) / math.Sqrt(2*math.Pi) / (s * vt)
+	}
+	return net * s * s * 0.01
+}
+
+// zeroGamma scans netGamma between half and one and a half times price and returns the sign change
+// closest to price, refined by bisection, or NaN.
+func zeroGamma(legs []gammaLeg, price, rate float64) float64 {
+	const steps = 200
+	lo, step := price/2, price/steps
+
+	zero := math.NaN()
+	s0, g0 := lo, netGamma(legs, lo, rate)
+	for i := 1; i <= steps; i++ {
+		s1 := lo + float64(i)*step
+		g1 := netGamma(legs, s1, rate)
+		if z, ok := crossing(legs, rate, s0, g0, s1, g1); ok && (math.IsNaN(zero) || math.Abs(z-price) < math.Abs(zero-price)) {
+			zero = z
+		}
+		s0, g0 = s1, g1
+	}
+	return zero
+}
+
+// crossing bisects [a, b] if the net exposure changes sign between them.
+func crossing(legs []gammaLeg, rate, a, ga, b, gb float64) (float64, bool) {
+	switch {
+	case ga == 0:
+		return a, true
+	case gb == 0 || (ga < 0) == (gb < 0):
+		// a zero at b is found as the start of the next interval
+		return 0, false
+	}
+	for i := 0; i < 50; i++ {
+		m := (a + b) / 2
+		gm := netGamma(legs, m, rate)
+		if gm == 0 {
+			return m, true
+		}
+		if (gm < 0) == (ga < 0) {
+			a, ga = m, gm
+		} else {
+			b = m
+		}
+	}
+	return (a + b) / 2, true
+}
+
+// groupByExpiration splits options sorted by sortOptions into expirations.
+func groupByExpiration(opts []*Option) (out [][]*Option) {
+	for i := 0; i < len(opts); {
+		j := i + 1
+		for j < len(opts) && opts[j].ExpirationDate == opts[i].ExpirationDate {
+			j++
+		}
+		out = append(out, opts[i:j])
+		i = j
+	}
+	return
+}
@@ -0,0 +1,165 @@
+package td
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func analyticsChain() *OptionChain {
+	exp1 := time.Date(2020, 8, 21, 16, 0, 0, 0, nytz)
+	exp2 := time.Date(2020, 9, 18, 16, 0, 0, 0, nytz)
+	opt := func(pc PutCall, exp time.Time, dte int, strike float64, oi, vol int, gamma float64) []*Option {
+		return []*Option{{
+			PutCall:          string(pc),
+			Symbol:           OptionSymbol{"XYZ", exp, pc, strike}.String(),
+			ExpirationDate:   exp.UnixNano() / int64(time.Millisecond),
+			DaysToExpiration: dte,
+			StrikePrice:      strike,
+			OpenInterest:     oi,
+			TotalVolume:      vol,
+			Gamma:            gamma,
+		}}
+	}
+
+	return &OptionChain{
+		Symbol:          "XYZ",
+		UnderlyingPrice: 100,
+		CallExpDateMap: CallExpDateMap{
+			"2020-08-21:7": {
+				"90.0":  opt(PutCallCall, exp1, 7, 90, 100, 10, 0.0001),
+				"100.0": opt(PutCallCall, exp1, 7, 100, 200, 20, 0.0001),
+				"110.0": opt(PutCallCall, exp1, 7, 110, 400, 30, 0.0001),
+			},
+			"2020-09-18:35": {
+				"100.0": opt(PutCallCall, exp2, 35, 100, 50, 5, 0.0001),
+			},
+		},
+		PutExpDateMap: PutExpDateMap{
+			"2020-08-21:7": {
+				"90.0":  opt(PutCallPut, exp1, 7, 90, 300, 30, 0.0001),
+				"100.0": opt(PutCallPut, exp1, 7, 100, 200, 20, 0.0001),
+				"110.0": opt(PutCallPut, exp1, 7, 110, 100, 10, math.NaN()),
+			},
+			"2020-09-18:35": {
+				"100.0": opt(PutCallPut, exp2, 35, 100, 150, 15, 0.0001),
+			},
+		},
+	}
+}
+
+func TestMaxPain(t *testing.T) {
+	mp := analyticsChain().MaxPain()
+	if len(mp) != 2 {
+		t.Fatalf("expected 2 expirations, got %+v", mp)
+	}
+	if mp[0].Expiration.Day() != 21 || mp[0].Strike != 100 || mp[0].Payout != 200000 {
+		t.Fatalf("unexpected max pain: %+v", mp[0])
+	}
+	if mp[1].Strike != 100 || mp[1].Payout != 0 {
+		t.Fatalf("unexpected max pain: %+v", mp[1])
+	}
+}
+
+func TestPutCallRatios(t *testing.T) {
+	oc := analyticsChain()
+
+	r := oc.PutCallRatio()
+	if !r.Expiration.IsZero() || r.PutVolume != 75 || r.CallVolume != 65 || r.PutOpenInterest != 750 || r.CallOpenInterest != 750 {
+		t.Fatalf("unexpected totals: %+v", r)
+	}
+	if r.Volume != 75.0/65 || r.OpenInterest != 1 {
+		t.Fatalf("unexpected ratios: %+v", r)
+	}
+
+	rs := oc.PutCallRatios()
+	if len(rs) != 2 || rs[0].Volume != 1 || rs[1].Volume != 3 || rs[1].OpenInterest != 3 || rs[1].Expiration.Month() != time.September {
+		t.Fatalf("unexpected ratios: %+v", rs)
+	}
+
+	if r := (&OptionChain{}).PutCallRatio(); !math.IsNaN(r.Volume) || !math.IsNaN(r.OpenInterest) {
+		t.Fatalf("expected NaN ratios without calls, got %+v", r)
+	}
+}
+
+func TestGammaExposure(t *testing.T) {
+	oc := analyticsChain()
+
+	// gamma 0.0001 * 100 shares * 100² * 1% = $1 per contract
+	ge := oc.GammaExposure(nil)
+	exp := []StrikeGamma{{90, 100, -300, -200}, {100, 250, -350, -100}, {110, 400, 0, 400}}
+	if len(ge.ByStrike) != len(exp) {
+		t.Fatalf("expected %+v, got %+v", exp, ge.ByStrike)
+	}
+	for i, sg := range ge.ByStrike {
+		e := exp[i]
+		if sg.Strike != e.Strike || math.Abs(sg.Call-e.Call) > 1e-9 || math.Abs(sg.Put-e.Put) > 1e-9 || math.Abs(sg.Net-e.Net) > 1e-9 {
+			t.Fatalf("expected %+v, got %+v", exp, ge.ByStrike)
+		}
+	}
+	if math.Abs(ge.Net-100) > 1e-9 || !math.IsNaN(ge.ZeroGamma) {
+		t.Fatalf("unexpected net %v or zero gamma %v without volatilities", ge.Net, ge.ZeroGamma)
+	}
+}
+
+func TestZeroGamma(t *testing.T) {
+	exp := time.Date(2020, 10, 26, 16, 0, 0, 0, nytz)
+	opt := func(pc PutCall, strike float64) []*Option {
+		return []*Option{{
+			PutCall:          string(pc),
+			Symbol:           OptionSymbol{"XYZ", exp, pc, strike}.String(),
+			ExpirationDate:   exp.UnixNano() / int64(time.Millisecond),
+			DaysToExpiration: 73,
+			StrikePrice:      strike,
+			OpenInterest:     1000,
+			Gamma:            0.01,
+		}}
+	}
+	oc := &OptionChain{
+		Symbol:          "XYZ",
+		UnderlyingPrice: 100,
+		Volatility:      20,
+		CallExpDateMap:  CallExpDateMap{"2020-10-26:73": {"110.0": opt(PutCallCall, 110)}},
+		PutExpDateMap:   PutExpDateMap{"2020-10-26:73": {"90.0": opt(PutCallPut, 90)}},
+	}
+
+	// with the same open interest, volatility and expiration and no rates, the gammas of the call and the put
+	// are equal when d1(110) = -d1(90): ln(S²/(110*90)) = -σ²T, S = √9900 * e^(-0.2²*0.2/2) ≈ 99.1015
+	ge := oc.GammaExposure(nil)
+	if exp := math.Sqrt(9900) * math.Exp(-0.004); math.Abs(ge.ZeroGamma-exp) > 1e-6 {
+		t.Fatalf("expected zero gamma at %v, got %v", exp, ge.ZeroGamma)
+	}
+	// the gammas from the chain are still used for the exposure at the current price
+	if ge.Net != 0 {
+		t.Fatalf("expected a net exposure of 0, got %v", ge.Net)
+	}
+
+	// the option's volatility overrides the chain's: a more volatile put has a flatter gamma,
+	// so the calls take over at a lower price
+	oc.PutExpDateMap["2020-10-26:73"]["90.0"][0].Volatility = 40
+	if ge := oc.GammaExposure(nil); ge.ZeroGamma >= 99.1 {
+		t.Fatalf("expected zero gamma to move down, got %v", ge.ZeroGamma)
+	}
+
+	if ge := oc.GammaExposure(&OptionFilter{PutCall: PutCallCall}); !math.IsNaN(ge.ZeroGamma) {
+		t.Fatalf("expected no zero gamma for calls only, got %v", ge.ZeroGamma)
+	}
+}
+
+func TestContractMultiplier(t *testing.T) {
+	if m := (&Option{}).ContractMultiplier(); m != 100 {
+		t.Fatalf("expected the default of 100, got %v", m)
+	}
+	if m := (&Option{Multiplier: 10}).ContractMultiplier(); m != 10 {
+		t.Fatalf("expected 10, got %v", m)
+	}
+
+	// mini options have a tenth of the exposure
+	oc := analyticsChain()
+	for _, o := range oc.Options() {
+		o.Multiplier = 10
+	}
+	if ge := oc.GammaExposure(nil); math.Abs(ge.Net-10) > 1e-9 {
+		t.Fatalf("expected a net exposure of 10, got %v", ge.Net)
+	}
+}
@@ -0,0 +1,219 @@
+package td
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const defaultNearTheMoneyPct = 0.05
+
+// Expiration returns the expiration time of the option.
+func (o *Option) Expiration() time.Time {
+	return time.Unix(0, o.ExpirationDate*int64(time.Millisecond)).In(nytz)
+}
+
+// IsCall returns true if o is a call.
+func (o *Option) IsCall() bool { return PutCall(o.PutCall) == PutCallCall }
+
+// Spread returns the difference between the ask and the bid.
+func (o *Option) Spread() float64 { return o.Ask - o.Bid }
+
+// ITM returns true if the option is in the money at the given underlying price,
+// if the price is 0 it falls back to InTheMoney.
+func (o *Option) ITM(underlyingPrice float64) bool {
+	if underlyingPrice <= 0 {
+		return o.InTheMoney
+	}
+	if o.IsCall() {
+		return o.StrikePrice < underlyingPrice
+	}
+	return o.StrikePrice > underlyingPrice
+}
+
+// Options returns all the calls and puts of the chain sorted by expiration, strike, then calls before puts.
+func (oc *OptionChain) Options() []*Option {
+	out := appendOptions(nil, oc.CallExpDateMap)
+	out = appendOptions(out, oc.PutExpDateMap)
+	sortOptions(out)
+	return out
+}
+
+// Calls returns the calls of the chain sorted by expiration then strike.
+func (oc *OptionChain) Calls() []*Option {
+	out := appendOptions(nil, oc.CallExpDateMap)
+	sortOptions(out)
+	return out
+}
+
+// Puts returns the puts of the chain sorted by expiration then strike.
+func (oc *OptionChain) Puts() []*Option {
+	out := appendOptions(nil, oc.PutExpDateMap)
+	sortOptions(out)
+	return out
+}
+
+// Expirations returns the sorted expiration dates of the chain.
+func (oc *OptionChain) Expirations() (out []time.Time) {
+	seen := map[int64]bool{}
+	for _, o := range oc.Options() {
+		if !seen[o.ExpirationDate] {
+			seen[o.ExpirationDate] = true
+			out = append(out, o.Expiration())
+		}
+	}
+	return
+}
+
+// Filter returns the options of the chain matching f, sorted like Options.
+func (oc *OptionChain) Filter(f *OptionFilter) []*Option {
+	return f.Apply(oc.Options(), oc.UnderlyingPrice)
+}
+
+func appendOptions(out []*Option, m map[string]map[Strike][]*Option) []*Option {
+	for _, strikes := range m {
+		for _, opts := range strikes {
+			for _, o := range opts {
+				if o != nil {
+					out = append(out, o)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func sortOptions(opts []*Option) {
+	sort.Slice(opts, func(i, j int) bool {
+		a, b := opts[i], opts[j]
+		switch {
+		case a.ExpirationDate != b.ExpirationDate:
+			return a.ExpirationDate < b.ExpirationDate
+		case a.StrikePrice != b.StrikePrice:
+			return a.StrikePrice < b.StrikePrice
+		case a.IsCall() != b.IsCall():
+			return a.IsCall()
+		default:
+			return a.Symbol < b.Symbol
+		}
+	})
+}
+
+// OptionFilter selects options for screening, zero values disable the matching check.
+type OptionFilter struct {
+	// PutCall limits the options to calls or puts, empty returns both.
+	PutCall PutCall
+
+	// Expirations between MinExpiration and MaxExpiration, inclusive.
+	MinExpiration time.Time
+	MaxExpiration time.Time
+
+	// Days to expiration between MinDTE and MaxDTE, inclusive.
+	MinDTE int
+	MaxDTE int
+
+	// Strikes between MinStrike and MaxStrike, inclusive.
+	MinStrike float64
+	MaxStrike float64
+
+	// Range supports InTheMoney, OutOfTheMoney and NearTheMoney, near the money is within NearTheMoneyPct
+	// of the underlying price, default is 5%.
+	Range           StrikeRange
+	NearTheMoneyPct float64
+
+	// The absolute delta between MinDelta and MaxDelta, inclusive. Options with invalid deltas are excluded.
+	MinDelta float64
+	MaxDelta float64
+
+	MinOpenInterest int
+	MinVolume       int
+
+	// MaxSpread is the max ask - bid, MaxSpreadPct is the max spread relative to the mark (0.1 is 10%).
+	MaxSpread    float64
+	MaxSpreadPct float64
+}
+
+// Apply returns the options matching f, underlyingPrice is used for Range.
+func (f *OptionFilter) Apply(opts []*Option, underlyingPrice float64) []*Option {
+	out := make([]*Option, 0, len(opts))
+	for _, o := range opts {
+		if f.Match(o, underlyingPrice) {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// Match returns true if o matches f, a nil filter matches everything.
+func (f *OptionFilter) Match(o *Option, underlyingPrice float64) bool {
+	if f == nil {
+		return true
+	}
+
+	if f.PutCall != "" && PutCall(o.PutCall) != f.PutCall {
+		return false
+	}
+
+	if !f.MinExpiration.IsZero() || !f.MaxExpiration.IsZero() {
+		exp := o.Expiration()
+		if !f.MinExpiration.IsZero() && exp.Before(f.MinExpiration) {
+			return false
+		}
+		if !f.MaxExpiration.IsZero() && exp.After(f.MaxExpiration) {
+			return false
+		}
+	}
+
+	if o.DaysToExpiration < f.MinDTE || (f.MaxDTE > 0 && o.DaysToExpiration > f.MaxDTE) {
+		return false
+	}
+
+	if o.StrikePrice < f.MinStrike || (f.MaxStrike > 0 && o.StrikePrice > f.MaxStrike) {
+		return false
+	}
+
+	switch f.Range {
+	case InTheMoney:
+		if !o.ITM(underlyingPrice) {
+			return false
+		}
+	case OutOfTheMoney:
+		if o.ITM(underlyingPrice) {
+			return false
+		}
+	case NearTheMoney:
+		pct := f.NearTheMoneyPct
+		if pct <= 0 {
+			pct = defaultNearTheMoneyPct
+		}
+		if underlyingPrice <= 0 || math.Abs(o.StrikePrice-underlyingPrice)/underlyingPrice > pct {
+			return false
+		}
+	}
+
+	if f.MinDelta > 0 || f.MaxDelta > 0 {
+		d := math.Abs(o.Delta)
+		if !ValidGreek(o.Delta) || d < f.MinDelta || (f.MaxDelta > 0 && d > f.MaxDelta) {
+			return false
+		}
+	}
+
+	if o.OpenInterest < f.MinOpenInterest || o.TotalVolume < f.MinVolume {
+		return false
+	}
+
+	if f.MaxSpread > 0 && (o.Ask <= 0 || o.Spread() > f.MaxSpread) {
+		return false
+	}
+
+	if f.MaxSpreadPct > 0 && (o.Mark <= 0 || o.Ask <= 0 || o.Spread()/o.Mark > f.MaxSpreadPct) {
+		return false
+	}
+
+	return true
+}
+
+// ValidGreek returns false for the NaN and -999 placeholders TD returns when a greek or a value isn't available.
+func ValidGreek(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0) && v != -999
+}
@@ -0,0 +1,124 @@
+package td
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func testChain() *OptionChain {
+	exp1 := time.Date(2020, 8, 14, 16, 0, 0, 0, nytz)
+	exp2 := time.Date(2020, 8, 21, 16, 0, 0, 0, nytz)
+	opt := func(pc PutCall, exp time.Time, dte int, strike, delta float64, oi, vol int, bid, ask float64) *Option {
+		return &Option{
+			PutCall:          string(pc),
+			Symbol:           OptionSymbol{"AMD", exp, pc, strike}.String(),
+			ExpirationDate:   exp.UnixNano() / int64(time.Millisecond),
+			DaysToExpiration: dte,
+			StrikePrice:      strike,
+			Delta:            delta,
+			OpenInterest:     oi,
+			TotalVolume:      vol,
+			Bid:              bid,
+			Ask:              ask,
+			Mark:             (bid + ask) / 2,
+		}
+	}
+
+	return &OptionChain{
+		Symbol:          "AMD",
+		UnderlyingPrice: 80,
+		CallExpDateMap: CallExpDateMap{
+			"2020-08-21:7": {
+				"75.0": {opt(PutCallCall, exp2, 7, 75, 0.8, 100, 10, 5.5, 5.7)},
+			},
+			"2020-08-14:0": {
+				"85.0": {opt(PutCallCall, exp1, 0, 85, 0.1, 50, 0, 0.1, 0.3)},
+				"80.0": {opt(PutCallCall, exp1, 0, 80, 0.5, 1000, 500, 1.0, 1.05)},
+			},
+		},
+		PutExpDateMap: PutExpDateMap{
+			"2020-08-14:0": {
+				"80.0": {opt(PutCallPut, exp1, 0, 80, -0.5, 900, 400, 0.95, 1.0)},
+				"70.0": {opt(PutCallPut, exp1, 0, 70, math.NaN(), 0, 0, 0, 0.05)},
+			},
+		},
+	}
+}
+
+func TestOptionChainFlatten(t *testing.T) {
+	oc := testChain()
+
+	var got []string
+	for _, o := range oc.Options() {
+		got = append(got, o.Symbol)
+	}
+	exp := []string{"AMD_081420P70", "AMD_081420C80", "AMD_081420P80", "AMD_081420C85", "AMD_082120C75"}
+	if len(got) != len(exp) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+	for i := range exp {
+		if got[i] != exp[i] {
+			t.Fatalf("expected %v, got %v", exp, got)
+		}
+	}
+
+	if n := len(oc.Calls()); n != 3 {
+		t.Fatalf("expected 3 calls, got %d", n)
+	}
+	if exps := oc.Expirations(); len(exps) != 2 || exps[0].Day() != 14 || exps[1].Day() != 21 {
+		t.Fatalf("unexpected expirations: %v", exps)
+	}
+}
+
+func TestOptionChainFilter(t *testing.T) {
+	oc := testChain()
+	tests := []struct {
+		name string
+		f    OptionFilter
+		exp  []string
+	}{
+		{"puts", OptionFilter{PutCall: PutCallPut}, []string{"AMD_081420P70", "AMD_081420P80"}},
+		{"dte", OptionFilter{MinDTE: 1}, []string{"AMD_082120C75"}},
+		{"max dte", OptionFilter{MaxDTE: 3, PutCall: PutCallCall}, []string{"AMD_081420C80", "AMD_081420C85"}},
+		{"expiration", OptionFilter{MinExpiration: time.Date(2020, 8, 15, 0, 0, 0, 0, nytz)}, []string{"AMD_082120C75"}},
+		{"strikes", OptionFilter{MinStrike: 75, MaxStrike: 80}, []string{"AMD_081420C80", "AMD_081420P80", "AMD_082120C75"}},
+		{"itm", OptionFilter{Range: InTheMoney}, []string{"AMD_082120C75"}},
+		{"otm", OptionFilter{Range: OutOfTheMoney, PutCall: PutCallCall}, []string{"AMD_081420C80", "AMD_081420C85"}},
+		{"ntm", OptionFilter{Range: NearTheMoney, NearTheMoneyPct: 0.01}, []string{"AMD_081420C80", "AMD_081420P80"}},
+		{"delta", OptionFilter{MinDelta: 0.4, MaxDelta: 0.6}, []string{"AMD_081420C80", "AMD_081420P80"}},
+		{"liquidity", OptionFilter{MinOpenInterest: 100, MinVolume: 100}, []string{"AMD_081420C80", "AMD_081420P80"}},
+		{"spread", OptionFilter{MaxSpread: 0.1}, []string{"AMD_081420P70", "AMD_081420C80", "AMD_081420P80"}},
+		{"spread pct", OptionFilter{MaxSpreadPct: 0.1}, []string{"AMD_081420C80", "AMD_081420P80", "AMD_082120C75"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, o := range oc.Filter(&tc.f) {
+				got = append(got, o.Symbol)
+			}
+			if len(got) != len(tc.exp) {
+				t.Fatalf("expected %v, got %v", tc.exp, got)
+			}
+			for i := range got {
+				if got[i] != tc.exp[i] {
+					t.Fatalf("expected %v, got %v", tc.exp, got)
+				}
+			}
+		})
+	}
+}
+
+func TestValidGreek(t *testing.T) {
+	for _, v := range []float64{0, 0.5, -0.5, -998} {
+		if !ValidGreek(v) {
+			t.Fatalf("expected %v to be valid", v)
+		}
+	}
+	for _, v := range []float64{-999, math.NaN(), math.Inf(1), math.Inf(-1)} {
+		if ValidGreek(v) {
+			t.Fatalf("expected %v to be invalid", v)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package td
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidOptionSymbol = errors.New("invalid option symbol")
+
+// OptionSymbolError is returned when an option symbol can't be parsed, it matches ErrInvalidOptionSymbol with errors.Is.
+type OptionSymbolError struct {
+	Symbol string
+	Reason string
+}
+
+func (e *OptionSymbolError) Error() string {
+	return fmt.Sprintf("invalid option symbol %q: %s", e.Symbol, e.Reason)
+}
+
+func (e *OptionSymbolError) Is(target error) bool { return target == ErrInvalidOptionSymbol }
+
+const (
+	occSymbolLen  = 21
+	occRootLen    = 6
+	maxOCCStrike  = 99999.999
+	tdDateFormat  = "010206"
+	occDateFormat = "060102"
+)
+
+// OptionSymbol is a parsed option symbol, String returns TD's format (AMD_081420C80) and OCC returns
+// the 21 characters OCC format (AMD   200814C00080000).
+type OptionSymbol struct {
+	Underlying string
+	Expiration time.Time // midnight New York time
+	PutCall    PutCall
+	Strike     float64
+}
+
+// ParseOptionSymbol parses either TD's or the OCC format.
+func ParseOptionSymbol(s string) (OptionSymbol, error) {
+	if strings.IndexByte(s, '_') != -1 {
+		return ParseTDOptionSymbol(s)
+	}
+	return ParseOCCOptionSymbol(s)
+}
+
+// ParseTDOptionSymbol parses TD's format: underlying_MMDDYY[C|P]strike, for example AMD_081420C80 or SPY_082120P337.5.
+func ParseTDOptionSymbol(s string) (o OptionSymbol, err error) {
+	i := strings.IndexByte(s, '_')
+	if i < 1 {
+		return o, &OptionSymbolError{s, "missing the underlying"}
+	}
+	rest := s[i+1:]
+	if len(rest) < len(tdDateFormat)+2 {
+		return o, &OptionSymbolError{s, "too short"}
+	}
+
+	o.Underlying = s[:i]
+	if o.Expiration, err = time.ParseInLocation(tdDateFormat, rest[:len(tdDateFormat)], nytz); err != nil {
+		return o, &OptionSymbolError{s, "invalid expiration date"}
+	}
+	if o.PutCall, err = parsePutCall(s, rest[len(tdDateFormat)]); err != nil {
+		return
+	}
+	strike := rest[len(tdDateFormat)+1:]
+	if o.Strike, err = strconv.ParseFloat(strike, 64); err != nil || strings.ContainsAny(strike, "eE+-") {
+		return o, &OptionSymbolError{s, "invalid strike"}
+	}
+
+	if err = o.validate(s); err != nil {
+		return OptionSymbol{}, err
+	}
+	return o, nil
+}
+
+// ParseOCCOptionSymbol parses the 21 characters OCC format: the underlying padded with spaces to 6 characters,
+// YYMMDD, C or P and the strike multiplied by 1000 padded with zeros to 8 digits.
+func ParseOCCOptionSymbol(s string) (o OptionSymbol, err error) {
+	if len(s) != occSymbolLen {
+		return o, &OptionSymbolError{s, fmt.Sprintf("expected %d characters, got %d", occSymbolLen, len(s))}
+	}
+
+	o.Underlying = strings.TrimRight(s[:occRootLen], " ")
+	if o.Expiration, err = time.ParseInLocation(occDateFormat, s[occRootLen:occRootLen+6], nytz); err != nil {
+		return o, &OptionSymbolError{s, "invalid expiration date"}
+	}
+	if o.PutCall, err = parsePutCall(s, s[occRootLen+6]); err != nil {
+		return
+	}
+
+	strike := s[occRootLen+7:]
+	n, perr := strconv.ParseUint(strike, 10, 64)
+	if perr != nil {
+		return o, &OptionSymbolError{s, "invalid strike"}
+	}
+	o.Strike = float64(n) / 1000
+
+	if err = o.validate(s); err != nil {
+		return OptionSymbol{}, err
+	}
+	return o, nil
+}
+
+func parsePutCall(s string, c byte) (PutCall, error) {
+	switch c {
+	case 'C', 'c':
+		return PutCallCall, nil
+	case 'P', 'p':
+		return PutCallPut, nil
+	default:
+		return "", &OptionSymbolError{s, "expected C or P"}
+	}
+}
+
+// Validate checks that o can be formatted in both TD's and the OCC format.
+func (o OptionSymbol) Validate() error {
+	return o.validate(o.String())
+}
+
+func (o OptionSymbol) validate(s string) error {
+	switch {
+	case o.Underlying == "":
+		return &OptionSymbolError{s, "missing the underlying"}
+	case len(o.Underlying) > occRootLen:
+		return &OptionSymbolError{s, fmt.Sprintf("the underlying is longer than %d characters", occRootLen)}
+	case strings.ContainsAny(o.Underlying, " _"):
+		return &OptionSymbolError{s, "the underlying can't contain spaces or underscores"}
+	case o.Expiration.IsZero():
+		return &OptionSymbolError{s, "missing the expiration date"}
+	case o.PutCall != PutCallCall && o.PutCall != PutCallPut:
+		return &OptionSymbolError{s, "invalid put/call"}
+	case !(o.Strike > 0 && o.Strike <= maxOCCStrike): // also catches NaN
+		return &OptionSymbolError{s, "strike out of range"}
+	case math.Abs(o.Strike*1000-math.Round(o.Strike*1000)) > 1e-6:
+		return &OptionSymbolError{s, "strike has more than 3 decimals"}
+	}
+	return nil
+}
+
+// String returns the symbol in TD's format.
+func (o OptionSymbol) String() string {
+	return o.Underlying + "_" + o.Expiration.Format(tdDateFormat) + o.putCallChar() + strconv.FormatFloat(o.Strike, 'f', -1, 64)
+}
+
+// OCC returns the symbol in the 21 characters OCC format.
+func (o OptionSymbol) OCC() string {
+	return fmt.Sprintf("%-6s%s%s%08d", o.Underlying, o.Expiration.Format(occDateFormat), o.putCallChar(), int64(math.Round(o.Strike*1000)))
+}
+
+func (o OptionSymbol) putCallChar() string {
+	if o.PutCall == PutCallPut {
+		return "P"
+	}
+	return "C"
+}
+
+// IsCall returns true if o is a call.
+func (o OptionSymbol) IsCall() bool { return o.PutCall == PutCallCall }
+
+// DaysToExpiration returns the number of calendar days between t and the expiration date.
+func (o OptionSymbol) DaysToExpiration(t time.Time) int {
+	t = t.In(nytz)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, nytz)
+	return int(math.Round(o.Expiration.Sub(day).Hours() / 24))
+}
+
+// OptionSymbol parses the option's TD symbol.
+func (o *Option) OptionSymbol() (OptionSymbol, error) {
+	return ParseTDOptionSymbol(o.Symbol)
+}
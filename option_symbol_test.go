@@ -0,0 +1,75 @@
+package td
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOptionSymbol(t *testing.T) {
+	tests := []struct {
+		in     string
+		td     string
+		occ    string
+		strike float64
+		put    bool
+	}{
+		{"AMD_081420C80", "AMD_081420C80", "AMD   200814C00080000", 80, false},
+		{"SPY_082120P337.5", "SPY_082120P337.5", "SPY   200821P00337500", 337.5, true},
+		{"AMD   200814C00080000", "AMD_081420C80", "AMD   200814C00080000", 80, false},
+		{"SPXW  201231P03250000", "SPXW_123120P3250", "SPXW  201231P03250000", 3250, true},
+		{"F     210115C00007500", "F_011521C7.5", "F     210115C00007500", 7.5, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			o, err := ParseOptionSymbol(tc.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o.Strike != tc.strike || (o.PutCall == PutCallPut) != tc.put {
+				t.Fatalf("unexpected symbol: %+v", o)
+			}
+			if s := o.String(); s != tc.td {
+				t.Fatalf("expected %s, got %s", tc.td, s)
+			}
+			if s := o.OCC(); s != tc.occ {
+				t.Fatalf("expected %q, got %q", tc.occ, s)
+			}
+		})
+	}
+
+	o, _ := ParseTDOptionSymbol("AMD_081420C80")
+	if exp := time.Date(2020, 8, 14, 0, 0, 0, 0, nytz); !o.Expiration.Equal(exp) {
+		t.Fatalf("expected %v, got %v", exp, o.Expiration)
+	}
+	if dte := o.DaysToExpiration(time.Date(2020, 8, 10, 15, 0, 0, 0, nytz)); dte != 4 {
+		t.Fatalf("expected 4 days to expiration, got %d", dte)
+	}
+}
+
+func TestOptionSymbolErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"_081420C80",
+		"AMD_081420",
+		"AMD_131420C80",
+		"AMD_081420X80",
+		"AMD_081420C",
+		"AMD_081420C-80",
+		"AMD_081420C1e3",
+		"AMD_081420CNaN",
+		"AMD_081420C80.0001",
+		"TOOLONG_081420C80",
+		"AMD   200814C0008000",
+		"AMD   200814Q00080000",
+		"AMD   20081AC00080000",
+		"      200814C00080000",
+		"AMD   200814C0008000X",
+	} {
+		_, err := ParseOptionSymbol(in)
+		if !errors.Is(err, ErrInvalidOptionSymbol) {
+			t.Errorf("%q: expected ErrInvalidOptionSymbol, got %v", in, err)
+		}
+	}
+}
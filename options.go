@@ -2,10 +2,14 @@ package td
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
-	"go.oneofone.dev/anyx"
+	"golang.org/x/xerrors"
 )
 
 type OptionChain struct {
@@ -209,12 +213,12 @@ type OptionChainParams struct {
 	Range StrikeRange
 
 	// Only return expirations after this date. For strategies, expiration refers to the nearest term expiration in the strategy.
-	// Valid ISO-8601 formats are: 2006-01-02 and 2006-01-02T15:04:05
-	FromDate DateTime
+	// Only the date is sent, formatted as 2006-01-02.
+	FromDate time.Time
 
 	// Only return expirations before this date. For strategies, expiration refers to the nearest term expiration in the strategy.
-	// Valid ISO-8601 formats are: 2006-01-02 and 2006-01-02T15:04:05
-	ToDate DateTime
+	// Only the date is sent, formatted as 2006-01-02.
+	ToDate time.Time
 
 	// Volatility to use in calculations. Applies only to ANALYTICAL strategy chains (see strategy param).
 	Volatility float64
@@ -240,20 +244,149 @@ type OptionChainParams struct {
 	OptionType string
 }
 
+var ErrInvalidOptionChainParams = errors.New("invalid option chain params")
+
+const expMonths = "JAN,FEB,MAR,APR,MAY,JUN,JUL,AUG,SEP,OCT,NOV,DEC"
+
+// Validate checks the values and combinations of the params, the returned errors wrap ErrInvalidOptionChainParams.
+func (p *OptionChainParams) Validate() error {
+	if p == nil {
+		return nil
+	}
+
+	invalid := func(format string, args ...interface{}) error {
+		return xerrors.Errorf("%s: %w", fmt.Sprintf(format, args...), ErrInvalidOptionChainParams)
+	}
+
+	switch p.ContractType {
+	case "", PutContracts, CallContracts, AllContracts:
+	default:
+		return invalid("unknown contract type %q", p.ContractType)
+	}
+
+	switch p.Range {
+	case "", InTheMoney, NearTheMoney, OutOfTheMoney, StrikesAboveMarket, StrikesBelowMarket, StrikesNearMarket, AllStrikes:
+	default:
+		return invalid("unknown range %q", p.Range)
+	}
+
+	switch p.OptionType {
+	case "", "S", "NS", "ALL":
+	default:
+		return invalid("unknown option type %q", p.OptionType)
+	}
+
+	if m := strings.ToUpper(p.ExpMonth); m != "" && m != "ALL" && !strings.Contains(","+expMonths+",", ","+m+",") {
+		return invalid("invalid expiration month %q", p.ExpMonth)
+	}
+
+	if p.StrikeCount < 0 || p.Interval < 0 || p.Strike < 0 {
+		return invalid("strikeCount, interval and strike can't be negative")
+	}
+
+	if !p.FromDate.IsZero() && !p.ToDate.IsZero() && p.ToDate.Before(p.FromDate) {
+		return invalid("toDate is before fromDate")
+	}
+
+	if p.Strategy != StrategyAnalytical && (p.Volatility != 0 || p.UnderlyingPrice != 0 || p.InterestRate != 0 || p.DaysToExpiration != 0) {
+		return invalid("volatility, underlyingPrice, interestRate and daysToExpiration require the ANALYTICAL strategy")
+	}
+
+	if p.Volatility < 0 || p.UnderlyingPrice < 0 || p.DaysToExpiration < 0 {
+		return invalid("volatility, underlyingPrice and daysToExpiration can't be negative")
+	}
+
+	if p.Interval != 0 && (p.Strategy == "" || p.Strategy == StrategySingle || p.Strategy == StrategyAnalytical) {
+		return invalid("interval only applies to spread strategies")
+	}
+
+	return nil
+}
+
+// Query returns the encoded query string, only the set fields are included.
+func (p *OptionChainParams) Query() string {
+	if p == nil {
+		return ""
+	}
+	u := url.Values{}
+	if p.ContractType != "" {
+		u.Set("contractType", string(p.ContractType))
+	}
+
+	if p.StrikeCount != 0 {
+		u.Set("strikeCount", strconv.Itoa(p.StrikeCount))
+	}
+
+	if p.IncludeQuotes {
+		u.Set("includeQuotes", "TRUE")
+	}
+
+	if p.Strategy != "" {
+		u.Set("strategy", string(p.Strategy))
+	}
+
+	if p.Interval != 0 {
+		u.Set("interval", strconv.Itoa(p.Interval))
+	}
+
+	if p.Strike != 0 {
+		u.Set("strike", formatFloat(p.Strike))
+	}
+
+	if p.Range != "" {
+		u.Set("range", string(p.Range))
+	}
+
+	if !p.FromDate.IsZero() {
+		u.Set("fromDate", p.FromDate.Format("2006-01-02"))
+	}
+
+	if !p.ToDate.IsZero() {
+		u.Set("toDate", p.ToDate.Format("2006-01-02"))
+	}
+
+	if p.Volatility != 0 {
+		u.Set("volatility", formatFloat(p.Volatility))
+	}
+
+	if p.UnderlyingPrice != 0 {
+		u.Set("underlyingPrice", formatFloat(p.UnderlyingPrice))
+	}
+
+	if p.InterestRate != 0 {
+		u.Set("interestRate", formatFloat(p.InterestRate))
+	}
+
+	if p.DaysToExpiration != 0 {
+		u.Set("daysToExpiration", strconv.Itoa(p.DaysToExpiration))
+	}
+
+	if p.ExpMonth != "" {
+		u.Set("expMonth", strings.ToUpper(p.ExpMonth))
+	}
+
+	if p.OptionType != "" {
+		u.Set("optionType", p.OptionType)
+	}
+
+	return u.Encode()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
 // OptionChain is complicated... check https://developer.tdameritrade.com/option-chains/apis/get/marketdata/chains
 func (c *Client) OptionChain(ctx context.Context, symbol string, params *OptionChainParams) (out *OptionChain, err error) {
-	args := url.Values{}
-	args.Set("symbol", symbol)
-	if params != nil {
-		pa := anyx.Value(params)
-		pa.ForEach(func(key anyx.A, value anyx.Any) (exit bool) {
-			k := key.(string)
-			k = strings.ToLower(k[:1]) + k[1:]
-			args.Set(k, value.String(true))
-			return
-		})
+	if err = params.Validate(); err != nil {
+		return
+	}
+
+	q := "symbol=" + url.QueryEscape(symbol)
+	if pq := params.Query(); pq != "" {
+		q += "&" + pq
 	}
 
-	err = c.Request(ctx, "GET", "marketdata/chains?"+args.Encode(), nil, &out)
+	err = c.Request(ctx, "GET", "marketdata/chains?"+q, nil, &out)
 	return
 }
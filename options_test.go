@@ -0,0 +1,70 @@
+package td
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOptionChainParamsQuery(t *testing.T) {
+	from := time.Date(2020, 8, 14, 15, 30, 0, 0, nytz)
+	tests := []struct {
+		name string
+		p    *OptionChainParams
+		want string
+	}{
+		{"nil", nil, ""},
+		{"empty", &OptionChainParams{}, ""},
+		{"basic", &OptionChainParams{ContractType: CallContracts, StrikeCount: 5, IncludeQuotes: true},
+			"contractType=CALL&includeQuotes=TRUE&strikeCount=5"},
+		{"dates", &OptionChainParams{FromDate: from, ToDate: from.AddDate(0, 1, 0)},
+			"fromDate=2020-08-14&toDate=2020-09-14"},
+		{"strike", &OptionChainParams{Strike: 337.5, Range: NearTheMoney}, "range=NTM&strike=337.5"},
+		{"spread", &OptionChainParams{Strategy: StrategyVertical, Interval: 5}, "interval=5&strategy=VERTICAL"},
+		{"analytical", &OptionChainParams{Strategy: StrategyAnalytical, Volatility: 29.5, UnderlyingPrice: 80,
+			InterestRate: 0.1, DaysToExpiration: 30},
+			"daysToExpiration=30&interestRate=0.1&strategy=ANALYTICAL&underlyingPrice=80&volatility=29.5"},
+		{"month", &OptionChainParams{ExpMonth: "aug", OptionType: "S"}, "expMonth=AUG&optionType=S"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.p.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if got := tc.p.Query(); got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestOptionChainParamsValidate(t *testing.T) {
+	from := time.Date(2020, 8, 14, 0, 0, 0, 0, nytz)
+	tests := []struct {
+		name string
+		p    *OptionChainParams
+	}{
+		{"contractType", &OptionChainParams{ContractType: "BOTH"}},
+		{"range", &OptionChainParams{Range: "XYZ"}},
+		{"optionType", &OptionChainParams{OptionType: "X"}},
+		{"expMonth", &OptionChainParams{ExpMonth: "AUGUST"}},
+		{"negative", &OptionChainParams{StrikeCount: -1}},
+		{"dates", &OptionChainParams{FromDate: from, ToDate: from.AddDate(0, 0, -1)}},
+		{"volatility", &OptionChainParams{Volatility: 30}},
+		{"underlyingPrice", &OptionChainParams{Strategy: StrategySingle, UnderlyingPrice: 80}},
+		{"daysToExpiration", &OptionChainParams{Strategy: StrategyVertical, DaysToExpiration: 30}},
+		{"interval", &OptionChainParams{Interval: 5}},
+		{"analyticalInterval", &OptionChainParams{Strategy: StrategyAnalytical, Interval: 5}},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.p.Validate(); !errors.Is(err, ErrInvalidOptionChainParams) {
+				t.Fatalf("expected ErrInvalidOptionChainParams, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package pricing
+
+import "math"
+
+// BjerksundStensland returns the value and Greeks of an American option using the Bjerksund-Stensland 2002
+// approximation, the Greeks are computed with finite differences.
+func BjerksundStensland(in Inputs) (Greeks, error) {
+	return Price(in, American)
+}
+
+func bjerksundStensland(in *Inputs) float64 {
+	if in.Years <= 0 {
+		return in.intrinsic()
+	}
+	b := in.Rate - in.DividendYield
+	if in.isCall() {
+		return bs2002Call(in.Underlying, in.Strike, in.Years, in.Rate, b, in.Volatility)
+	}
+	// put-call transformation: P(S, K, T, r, b, v) = C(K, S, T, r - b, -b, v)
+	return bs2002Call(in.Strike, in.Underlying, in.Years, in.Rate-b, -b, in.Volatility)
+}
+
+// earlyExercise reports whether an American option may be worth more than the European one,
+// calls without dividends and puts without a positive rate never are.
+func earlyExercise(in *Inputs) bool {
+	if in.isCall() {
+		return in.DividendYield > 0
+	}
+	return in.Rate > 0
+}
+
+func americanGreeks(in *Inputs) (g Greeks) {
+	if in.Years <= 0 {
+		return expiredGreeks(in)
+	}
+	if !earlyExercise(in) {
+		return blackScholesGreeks(in)
+	}
+
+	bumped := func(fn func(c *Inputs)) float64 {
+		c := *in
+		fn(&c)
+		return bjerksundStensland(&c)
+	}
+
+	g.Value = bjerksundStensland(in)
+
+	ds := in.Underlying * 1e-3
+	up := bumped(func(c *Inputs) { c.Underlying += ds })
+	down := bumped(func(c *Inputs) { c.Underlying -= ds })
+	g.Delta = (up - down) / (2 * ds)
+	g.Gamma = (up - 2*g.Value + down) / (ds * ds)
+
+	dv := math.Min(1e-3, in.Volatility/2)
+	g.Vega = (bumped(func(c *Inputs) { c.Volatility += dv }) - bumped(func(c *Inputs) { c.Volatility -= dv })) / (2 * dv) / 100
+
+	const dr = 1e-4
+	g.Rho = (bumped(func(c *Inputs) { c.Rate += dr }) - bumped(func(c *Inputs) { c.Rate -= dr })) / (2 * dr) / 100
+
+	const day = 1.0 / daysPerYear
+	if in.Years > day {
+		g.Theta = bumped(func(c *Inputs) { c.Years -= day }) - g.Value
+	} else {
+		g.Theta = (in.intrinsic() - g.Value) * day / in.Years
+	}
+	return
+}
+
+// europeanCall is the generalized Black-Scholes call with cost of carry b.
+func europeanCall(s, k, t, r, b, v float64) float64 {
+	vt := v * math.Sqrt(t)
+	d1 := (math.Log(s/k) + (b+v*v/2)*t) / vt
+	return s*math.Exp((b-r)*t)*normCDF(d1) - k*math.Exp(-r*t)*normCDF(d1-vt)
+}
+
+// bs2002Call is the Bjerksund-Stensland 2002 American call with cost of carry b.
+func bs2002Call(s, k, t, r, b, v float64) float64 {
+	euro := europeanCall(s, k, t, r, b, v)
+	if b >= r {
+		return euro
+	}
+
+	v2 := v * v
+	beta := (0.5 - b/v2) + math.Sqrt(math.Pow(b/v2-0.5, 2)+2*r/v2)
+	bInf := beta / (beta - 1) * k
+	b0 := math.Max(k, r/(r-b)*k)
+	t1 := 0.5 * (math.Sqrt(5) - 1) * t
+	ht1 := -(b*t1 + 2*v*math.Sqrt(t1)) * k * k / ((bInf - b0) * b0)
+	ht2 := -(b*t + 2*v*math.Sqrt(t)) * k * k / ((bInf - b0) * b0)
+	i1 := b0 + (bInf-b0)*(1-math.Exp(ht1))
+	i2 := b0 + (bInf-b0)*(1-math.Exp(ht2))
+
+	if s >= i2 {
+		return s - k
+	}
+
+	alpha1 := (i1 - k) * math.Pow(i1, -beta)
+	alpha2 := (i2 - k) * math.Pow(i2, -beta)
+
+	phi := func(t, gamma, h, i float64) float64 {
+		lambda := (-r + gamma*b + 0.5*gamma*(gamma-1)*v2) * t
+		vt := v * math.Sqrt(t)
+		d := -(math.Log(s/h) + (b+(gamma-0.5)*v2)*t) / vt
+		kappa := 2*b/v2 + 2*gamma - 1
+		return math.Exp(lambda) * math.Pow(s, gamma) * (normCDF(d) - math.Pow(i/s, kappa)*normCDF(d-2*math.Log(i/s)/vt))
+	}
+
+	ksi := func(t2, gamma, h, i2, i1, t1 float64) float64 {
+		m := (b + (gamma-0.5)*v2)
+		vt1, vt2 := v*math.Sqrt(t1), v*math.Sqrt(t2)
+		e1 := (math.Log(s/i1) + m*t1) / vt1
+		e2 := (math.Log(i2*i2/(s*i1)) + m*t1) / vt1
+		e3 := (math.Log(s/i1) - m*t1) / vt1
+		e4 := (math.Log(i2*i2/(s*i1)) - m*t1) / vt1
+		f1 := (math.Log(s/h) + m*t2) / vt2
+		f2 := (math.Log(i2*i2/(s*h)) + m*t2) / vt2
+		f3 := (math.Log(i1*i1/(s*h)) + m*t2) / vt2
+		f4 := (math.Log(s*i1*i1/(h*i2*i2)) + m*t2) / vt2
+		rho := math.Sqrt(t1 / t2)
+		lambda := -r + gamma*b + 0.5*gamma*(gamma-1)*v2
+		kappa := 2*b/v2 + 2*gamma - 1
+		return math.Exp(lambda*t2) * math.Pow(s, gamma) * (bivariateNormCDF(-e1, -f1, rho) -
+			math.Pow(i2/s, kappa)*bivariateNormCDF(-e2, -f2, rho) -
+			math.Pow(i1/s, kappa)*bivariateNormCDF(-e3, -f3, -rho) +
+			math.Pow(i1/i2, kappa)*bivariateNormCDF(-e4, -f4, -rho))
+	}
+
+	val := alpha2*math.Pow(s, beta) - alpha2*phi(t1, beta, i2, i2) +
+		phi(t1, 1, i2, i2) - phi(t1, 1, i1, i2) -
+		k*phi(t1, 0, i2, i2) + k*phi(t1, 0, i1, i2) +
+		alpha1*phi(t1, beta, i1, i2) - alpha1*ksi(t, beta, i1, i2, i1, t1) +
+		ksi(t, 1, i1, i2, i1, t1) - ksi(t, 1, k, i2, i1, t1) -
+		k*ksi(t, 0, i1, i2, i1, t1) + k*ksi(t, 0, k, i2, i1, t1)
+
+	// the approximation is a lower bound, it can't be worth less than the European option
+	if math.IsNaN(val) || val < euro {
+		return euro
+	}
+	return val
+}
@@ -0,0 +1,57 @@
+package pricing
+
+import "math"
+
+// BlackScholes returns the value and Greeks of a European option with a continuous dividend yield.
+func BlackScholes(in Inputs) (Greeks, error) {
+	return Price(in, European)
+}
+
+func bsD1D2(in *Inputs) (d1, d2 float64) {
+	vt := in.Volatility * math.Sqrt(in.Years)
+	d1 = (math.Log(in.Underlying/in.Strike) + (in.Rate-in.DividendYield+in.Volatility*in.Volatility/2)*in.Years) / vt
+	return d1, d1 - vt
+}
+
+func blackScholes(in *Inputs) float64 {
+	if in.Years <= 0 {
+		return in.intrinsic()
+	}
+	d1, d2 := bsD1D2(in)
+	s, k := in.Underlying*math.Exp(-in.DividendYield*in.Years), in.Strike*math.Exp(-in.Rate*in.Years)
+	if in.isCall() {
+		return s*normCDF(d1) - k*normCDF(d2)
+	}
+	return k*normCDF(-d2) - s*normCDF(-d1)
+}
+
+func blackScholesGreeks(in *Inputs) (g Greeks) {
+	if in.Years <= 0 {
+		return expiredGreeks(in)
+	}
+
+	d1, d2 := bsD1D2(in)
+	sqrtT := math.Sqrt(in.Years)
+	dq, dr := math.Exp(-in.DividendYield*in.Years), math.Exp(-in.Rate*in.Years)
+	s, k := in.Underlying*dq, in.Strike*dr
+	pdf := normPDF(d1)
+
+	g.Gamma = dq * pdf / (in.Underlying * in.Volatility * sqrtT)
+	g.Vega = s * pdf * sqrtT / 100
+	decay := -s * pdf * in.Volatility / (2 * sqrtT)
+
+	if in.isCall() {
+		nd1, nd2 := normCDF(d1), normCDF(d2)
+		g.Value = s*nd1 - k*nd2
+		g.Delta = dq * nd1
+		g.Theta = (decay - in.Rate*k*nd2 + in.DividendYield*s*nd1) / daysPerYear
+		g.Rho = k * in.Years * nd2 / 100
+	} else {
+		nd1, nd2 := normCDF(-d1), normCDF(-d2)
+		g.Value = k*nd2 - s*nd1
+		g.Delta = -dq * nd1
+		g.Theta = (decay + in.Rate*k*nd2 - in.DividendYield*s*nd1) / daysPerYear
+		g.Rho = -k * in.Years * nd2 / 100
+	}
+	return
+}
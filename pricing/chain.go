@@ -0,0 +1,137 @@
+package pricing
+
+import (
+	"time"
+
+	"go.oneofone.dev/td"
+	"golang.org/x/xerrors"
+)
+
+// YearsToExpiration returns the time left until expiration in years of 365 days, 0 if it already expired.
+func YearsToExpiration(expiration, now time.Time) float64 {
+	if d := expiration.Sub(now); d > 0 {
+		return d.Hours() / 24 / daysPerYear
+	}
+	return 0
+}
+
+// UnderlyingPrice returns the chain's underlying price, falling back to the underlying's mark then last price.
+func UnderlyingPrice(oc *td.OptionChain) float64 {
+	if oc.UnderlyingPrice > 0 {
+		return oc.UnderlyingPrice
+	}
+	if u := oc.Underlying; u != nil {
+		if u.Mark > 0 {
+			return u.Mark
+		}
+		return u.Last
+	}
+	return 0
+}
+
+// OptionInputs returns the inputs to price o at now: the chain's underlying price and interest rate,
+// and the option's volatility falling back to the chain's, TD's percents are converted to decimals.
+// The dividend yield is left to the caller.
+func OptionInputs(oc *td.OptionChain, o *td.Option, now time.Time) (Inputs, error) {
+	in := optionInputs(oc, o, now)
+	if err := in.Validate(); err != nil {
+		return in, xerrors.Errorf("%s: %w", o.Symbol, err)
+	}
+	return in, nil
+}
+
+func optionInputs(oc *td.OptionChain, o *td.Option, now time.Time) Inputs {
+	in := Inputs{
+		PutCall:    td.PutCall(o.PutCall),
+		Underlying: UnderlyingPrice(oc),
+		Strike:     o.StrikePrice,
+	}
+
+	if o.ExpirationDate != 0 {
+		in.Years = YearsToExpiration(o.Expiration(), now)
+	} else {
+		in.Years = float64(o.DaysToExpiration) / daysPerYear
+	}
+
+	if td.ValidGreek(oc.InterestRate) {
+		in.Rate = oc.InterestRate / 100
+	}
+
+	switch {
+	case td.ValidGreek(o.Volatility) && o.Volatility > 0:
+		in.Volatility = o.Volatility / 100
+	case td.ValidGreek(oc.Volatility) && oc.Volatility > 0:
+		in.Volatility = oc.Volatility / 100
+	}
+	return in
+}
+
+// FillParams controls FillGreeks, the zero value prices American options at time.Now() without dividends.
+type FillParams struct {
+	Style         Style
+	DividendYield float64
+
+	// Now is the pricing time, default is time.Now().
+	Now time.Time
+
+	// Overwrite recomputes all the Greeks instead of only the missing ones.
+	Overwrite bool
+}
+
+// FillGreeks computes the theoretical value and Greeks of the options in oc that are missing them:
+// NaN, -999, or all zeros when the chain was requested without quotes. It returns the number of options
+// that were updated, options without a usable volatility are skipped.
+func FillGreeks(oc *td.OptionChain, p *FillParams) (filled int) {
+	var fp FillParams
+	if p != nil {
+		fp = *p
+	}
+	if fp.Now.IsZero() {
+		fp.Now = time.Now()
+	}
+
+	for _, o := range oc.Options() {
+		if !fp.Overwrite && !missingGreeks(o) {
+			continue
+		}
+
+		in, err := OptionInputs(oc, o, fp.Now)
+		if err != nil {
+			continue
+		}
+		in.DividendYield = fp.DividendYield
+
+		g, err := Price(in, fp.Style)
+		if err != nil {
+			continue
+		}
+		fillOption(o, &g, fp.Overwrite)
+		filled++
+	}
+	return
+}
+
+func missingGreeks(o *td.Option) bool {
+	if o.Delta == 0 && o.Gamma == 0 && o.Theta == 0 && o.Vega == 0 && o.Rho == 0 {
+		return true
+	}
+	return !td.ValidGreek(o.Delta) || !td.ValidGreek(o.Gamma) || !td.ValidGreek(o.Theta) ||
+		!td.ValidGreek(o.Vega) || !td.ValidGreek(o.Rho) || !td.ValidGreek(o.TheoreticalOptionValue)
+}
+
+func fillOption(o *td.Option, g *Greeks, overwrite bool) {
+	allZero := o.Delta == 0 && o.Gamma == 0 && o.Theta == 0 && o.Vega == 0 && o.Rho == 0
+	set := func(dst *float64, v float64) {
+		if overwrite || allZero || !td.ValidGreek(*dst) {
+			*dst = v
+		}
+	}
+	set(&o.Delta, g.Delta)
+	set(&o.Gamma, g.Gamma)
+	set(&o.Theta, g.Theta)
+	set(&o.Vega, g.Vega)
+	set(&o.Rho, g.Rho)
+	if overwrite || o.TheoreticalOptionValue == 0 || !td.ValidGreek(o.TheoreticalOptionValue) {
+		o.TheoreticalOptionValue = g.Value
+	}
+}
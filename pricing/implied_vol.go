@@ -0,0 +1,152 @@
+package pricing
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"go.oneofone.dev/td"
+	"golang.org/x/xerrors"
+)
+
+var ErrNoImpliedVol = errors.New("no implied volatility")
+
+const (
+	minImpliedVol   = 1e-4
+	maxImpliedVol   = 5.0
+	defaultIVGuess  = 0.3
+	ivPriceTol      = 1e-6
+	ivMaxIterations = 100
+)
+
+// ImpliedVol returns the volatility that makes the model value of in equal to price, in.Volatility is used as
+// the initial guess if set. The returned errors wrap ErrNoImpliedVol when the price is outside of the values
+// the model can produce, for example at or below the intrinsic value.
+func ImpliedVol(in Inputs, style Style, price float64) (float64, error) {
+	if !(in.Volatility > 0) {
+		in.Volatility = defaultIVGuess
+	}
+	if err := in.Validate(); err != nil {
+		return math.NaN(), err
+	}
+	return impliedVol(&in, style, price)
+}
+
+// impliedVol is a Newton solver with the Black-Scholes vega, falling back to bisection when a step
+// leaves the bracket.
+func impliedVol(in *Inputs, style Style, price float64) (float64, error) {
+	if in.Years <= 0 {
+		return math.NaN(), xerrors.Errorf("expired option: %w", ErrNoImpliedVol)
+	}
+	if !(price > 0) || math.IsInf(price, 0) {
+		return math.NaN(), xerrors.Errorf("invalid price %v: %w", price, ErrNoImpliedVol)
+	}
+
+	c := *in
+	diff := func(v float64) float64 {
+		c.Volatility = v
+		return value(&c, style) - price
+	}
+
+	lo, hi := minImpliedVol, maxImpliedVol
+	if d := diff(lo); d > ivPriceTol {
+		return math.NaN(), xerrors.Errorf("price %v is below the minimum value %v: %w", price, d+price, ErrNoImpliedVol)
+	} else if d >= -ivPriceTol {
+		return math.NaN(), xerrors.Errorf("price %v has no time value: %w", price, ErrNoImpliedVol)
+	}
+	if d := diff(hi); d < -ivPriceTol {
+		return math.NaN(), xerrors.Errorf("price %v is above the maximum value %v: %w", price, d+price, ErrNoImpliedVol)
+	}
+
+	v := math.Min(math.Max(in.Volatility, lo), hi)
+	for i := 0; i < ivMaxIterations; i++ {
+		d := diff(v)
+		if math.Abs(d) <= ivPriceTol {
+			return v, nil
+		}
+		if d > 0 {
+			hi = v
+		} else {
+			lo = v
+		}
+		if hi-lo < 1e-12 {
+			return v, nil
+		}
+
+		c.Volatility = v
+		next := v - d/(blackScholesGreeks(&c).Vega*100)
+		if !(next > lo && next < hi) {
+			next = (lo + hi) / 2
+		}
+		v = next
+	}
+	return math.NaN(), xerrors.Errorf("didn't converge for price %v: %w", price, ErrNoImpliedVol)
+}
+
+// IVParams controls the implied volatility of chain options, the zero value uses American options
+// at time.Now() without dividends.
+type IVParams struct {
+	Style         Style
+	DividendYield float64
+
+	// Now is the pricing time, default is time.Now().
+	Now time.Time
+}
+
+func (p *IVParams) orDefault() (ip IVParams) {
+	if p != nil {
+		ip = *p
+	}
+	if ip.Now.IsZero() {
+		ip.Now = time.Now()
+	}
+	return
+}
+
+// IV is the implied volatility of an option's bid, mark and ask, NaN when there is no quote or it can't be solved.
+type IV struct {
+	Bid  float64
+	Mark float64
+	Ask  float64
+}
+
+// OptionIV solves the implied volatility of o's bid, mark and ask, TD's Volatility field is only used
+// as the initial guess. The mark falls back to the middle of the bid and ask then the last price,
+// an error is returned if the mark can't be solved.
+func OptionIV(oc *td.OptionChain, o *td.Option, p *IVParams) (iv IV, err error) {
+	ip := p.orDefault()
+	in := optionInputs(oc, o, ip.Now)
+	in.DividendYield = ip.DividendYield
+	if !(in.Volatility > 0) {
+		in.Volatility = defaultIVGuess
+	}
+	if err = in.Validate(); err != nil {
+		return IV{math.NaN(), math.NaN(), math.NaN()}, xerrors.Errorf("%s: %w", o.Symbol, err)
+	}
+
+	solve := func(price float64) (float64, error) {
+		if !(price > 0) {
+			return math.NaN(), xerrors.Errorf("no price: %w", ErrNoImpliedVol)
+		}
+		return impliedVol(&in, ip.Style, price)
+	}
+
+	iv.Bid, _ = solve(o.Bid)
+	iv.Ask, _ = solve(o.Ask)
+	if iv.Mark, err = solve(optionMark(o)); err != nil {
+		err = xerrors.Errorf("%s: %w", o.Symbol, err)
+	}
+	return
+}
+
+// optionMark returns the mark, the middle of the bid and ask or the last price, whichever is available first.
+func optionMark(o *td.Option) float64 {
+	switch {
+	case o.Mark > 0 && td.ValidGreek(o.Mark):
+		return o.Mark
+	case o.Bid > 0 && o.Ask > 0:
+		return (o.Bid + o.Ask) / 2
+	default:
+		return o.Last
+	}
+}
@@ -0,0 +1,114 @@
+package pricing
+
+import "math"
+
+// normCDF is the standard normal cumulative distribution function.
+func normCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// normPDF is the standard normal probability density function.
+func normPDF(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
+
+// Gauss-Legendre half nodes and weights for 6, 12 and 20 points.
+var (
+	glX = [3][]float64{
+		{-0.932469514203152, -0.661209386466265, -0.238619186083197},
+		{
+			-0.981560634246719, -0.904117256370475, -0.769902674194305,
+			-0.587317954286617, -0.36783149899818, -0.125233408511469,
+		},
+		{
+			-0.993128599185095, -0.963971927277914, -0.912234428251326, -0.839116971822219, -0.746331906460151,
+			-0.636053680726515, -0.510867001950827, -0.37370608871542, -0.227785851141645, -0.0765265211334973,
+		},
+	}
+	glW = [3][]float64{
+		{0.17132449237917, 0.360761573048138, 0.46791393457269},
+		{
+			0.0471753363865118, 0.106939325995318, 0.160078328543346,
+			0.203167426723066, 0.233492536538355, 0.249147045813403,
+		},
+		{
+			0.0176140071391521, 0.0406014298003869, 0.0626720483341091, 0.0832767415767048, 0.10193011981724,
+			0.118194531961518, 0.131688638449177, 0.142096109318382, 0.149172986472604, 0.152753387130726,
+		},
+	}
+)
+
+// bivariateNormCDF returns P(X <= x, Y <= y) for standard normals with correlation rho,
+// using Genz's algorithm (Genz 2004, West 2005).
+func bivariateNormCDF(x, y, rho float64) float64 {
+	var ng int
+	switch r := math.Abs(rho); {
+	case r < 0.3:
+		ng = 0
+	case r < 0.75:
+		ng = 1
+	default:
+		ng = 2
+	}
+	xs, ws := glX[ng], glW[ng]
+
+	h, k := -x, -y
+	hk := h * k
+	var bvn float64
+
+	if math.Abs(rho) < 0.925 {
+		if rho != 0 {
+			hs := (h*h + k*k) / 2
+			asr := math.Asin(rho)
+			for i := range xs {
+				for _, is := range [2]float64{-1, 1} {
+					sn := math.Sin(asr * (is*xs[i] + 1) / 2)
+					bvn += ws[i] * math.Exp((sn*hk-hs)/(1-sn*sn))
+				}
+			}
+			bvn *= asr / (4 * math.Pi)
+		}
+		return bvn + normCDF(-h)*normCDF(-k)
+	}
+
+	if rho < 0 {
+		k, hk = -k, -hk
+	}
+
+	if math.Abs(rho) < 1 {
+		as := (1 - rho) * (1 + rho)
+		a := math.Sqrt(as)
+		bs := (h - k) * (h - k)
+		c := (4 - hk) / 8
+		d := (12 - hk) / 16
+		if asr := -(bs/as + hk) / 2; asr > -100 {
+			bvn = a * math.Exp(asr) * (1 - c*(bs-as)*(1-d*bs/5)/3 + c*d*as*as/5)
+		}
+		if -hk < 100 {
+			b := math.Sqrt(bs)
+			bvn -= math.Exp(-hk/2) * math.Sqrt(2*math.Pi) * normCDF(-b/a) * b * (1 - c*bs*(1-d*bs/5)/3)
+		}
+		a /= 2
+		for i := range xs {
+			for _, is := range [2]float64{-1, 1} {
+				x2 := a * (is*xs[i] + 1)
+				x2 *= x2
+				rs := math.Sqrt(1 - x2)
+				if asr := -(bs/x2 + hk) / 2; asr > -100 {
+					bvn += a * ws[i] * math.Exp(asr) * (math.Exp(-hk*(1-rs)/(2*(1+rs)))/rs - (1 + c*x2*(1+d*x2)))
+				}
+			}
+		}
+		bvn = -bvn / (2 * math.Pi)
+	}
+
+	if rho > 0 {
+		return bvn + normCDF(-math.Max(h, k))
+	}
+
+	bvn = -bvn
+	if k > h {
+		bvn += normCDF(k) - normCDF(h)
+	}
+	return bvn
+}
@@ -0,0 +1,126 @@
+// Package pricing computes theoretical option values and Greeks with Black-Scholes (European)
+// and Bjerksund-Stensland 2002 (American), and fills missing Greeks on a td.OptionChain.
+package pricing // import "go.oneofone.dev/td/pricing"
+
+import (
+	"errors"
+	"math"
+
+	"go.oneofone.dev/td"
+	"golang.org/x/xerrors"
+)
+
+var ErrInvalidInputs = errors.New("invalid pricing inputs")
+
+// Style is the exercise style of an option.
+type Style int
+
+const (
+	// American options can be exercised any time before expiration, priced with Bjerksund-Stensland 2002.
+	American Style = iota
+	// European options can only be exercised at expiration, priced with Black-Scholes.
+	European
+)
+
+func (s Style) String() string {
+	if s == European {
+		return "European"
+	}
+	return "American"
+}
+
+const daysPerYear = 365
+
+// Inputs are the pricing model inputs, rates and volatility are decimals (0.05 is 5%), unlike TD's percents.
+type Inputs struct {
+	PutCall    td.PutCall
+	Underlying float64
+	Strike     float64
+
+	// Years to expiration, see YearsToExpiration.
+	Years float64
+
+	// Continuously compounded risk free rate.
+	Rate float64
+
+	// Continuous dividend yield.
+	DividendYield float64
+
+	Volatility float64
+}
+
+// Validate checks that the inputs can be priced, the returned errors wrap ErrInvalidInputs.
+func (in *Inputs) Validate() error {
+	switch {
+	case in.PutCall != td.PutCallCall && in.PutCall != td.PutCallPut:
+		return xerrors.Errorf("unknown put/call %q: %w", in.PutCall, ErrInvalidInputs)
+	case !(in.Underlying > 0) || math.IsInf(in.Underlying, 0):
+		return xerrors.Errorf("underlying price must be positive: %w", ErrInvalidInputs)
+	case !(in.Strike > 0) || math.IsInf(in.Strike, 0):
+		return xerrors.Errorf("strike must be positive: %w", ErrInvalidInputs)
+	case !(in.Years >= 0) || math.IsInf(in.Years, 0):
+		return xerrors.Errorf("years to expiration can't be negative: %w", ErrInvalidInputs)
+	case math.IsNaN(in.Rate) || math.IsInf(in.Rate, 0) || math.IsNaN(in.DividendYield) || math.IsInf(in.DividendYield, 0):
+		return xerrors.Errorf("invalid rate or dividend yield: %w", ErrInvalidInputs)
+	case in.Years > 0 && (!(in.Volatility > 0) || math.IsInf(in.Volatility, 0)):
+		return xerrors.Errorf("volatility must be positive: %w", ErrInvalidInputs)
+	}
+	return nil
+}
+
+func (in *Inputs) isCall() bool { return in.PutCall == td.PutCallCall }
+
+func (in *Inputs) intrinsic() float64 {
+	if in.isCall() {
+		return math.Max(in.Underlying-in.Strike, 0)
+	}
+	return math.Max(in.Strike-in.Underlying, 0)
+}
+
+// Greeks are the theoretical value and Greeks of an option, in TD's units:
+// Theta is per calendar day, Vega per 1% of volatility and Rho per 1% of interest rate.
+type Greeks struct {
+	Value float64
+	Delta float64
+	Gamma float64
+	Theta float64
+	Vega  float64
+	Rho   float64
+}
+
+// Price returns the value and Greeks of in with the model matching style.
+func Price(in Inputs, style Style) (Greeks, error) {
+	if err := in.Validate(); err != nil {
+		return Greeks{}, err
+	}
+	if style == European {
+		return blackScholesGreeks(&in), nil
+	}
+	return americanGreeks(&in), nil
+}
+
+// Value returns only the theoretical value of in, it's cheaper than Price for American options.
+func Value(in Inputs, style Style) (float64, error) {
+	if err := in.Validate(); err != nil {
+		return 0, err
+	}
+	return value(&in, style), nil
+}
+
+func value(in *Inputs, style Style) float64 {
+	if style == European {
+		return blackScholes(in)
+	}
+	return bjerksundStensland(in)
+}
+
+// expiredGreeks returns the intrinsic value and delta of an option at or past expiration.
+func expiredGreeks(in *Inputs) (g Greeks) {
+	g.Value = in.intrinsic()
+	if g.Value > 0 {
+		if g.Delta = 1; !in.isCall() {
+			g.Delta = -1
+		}
+	}
+	return
+}
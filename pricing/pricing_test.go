@@ -0,0 +1,236 @@
+package pricing
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"go.oneofone.dev/td"
+)
+
+func approx(a, b, tol float64) bool { return math.Abs(a-b) <= tol }
+
+// binomial prices an American option with a Cox-Ross-Rubinstein tree, it's the reference for BjerksundStensland.
+func binomial(in Inputs, steps int) float64 {
+	dt := in.Years / float64(steps)
+	u := math.Exp(in.Volatility * math.Sqrt(dt))
+	d := 1 / u
+	p := (math.Exp((in.Rate-in.DividendYield)*dt) - d) / (u - d)
+	disc := math.Exp(-in.Rate * dt)
+
+	payoff := func(s float64) float64 {
+		if in.PutCall == td.PutCallCall {
+			return math.Max(s-in.Strike, 0)
+		}
+		return math.Max(in.Strike-s, 0)
+	}
+
+	vals := make([]float64, steps+1)
+	for i := range vals {
+		vals[i] = payoff(in.Underlying * math.Pow(u, float64(i)) * math.Pow(d, float64(steps-i)))
+	}
+	for n := steps - 1; n >= 0; n-- {
+		for i := 0; i <= n; i++ {
+			s := in.Underlying * math.Pow(u, float64(i)) * math.Pow(d, float64(n-i))
+			vals[i] = math.Max(disc*(p*vals[i+1]+(1-p)*vals[i]), payoff(s))
+		}
+	}
+	return vals[0]
+}
+
+func TestBivariateNormCDF(t *testing.T) {
+	for _, rho := range []float64{-0.99, -0.95, -0.8, -0.5, -0.1, 0, 0.2, 0.5, 0.8, 0.95, 0.99} {
+		exp := 0.25 + math.Asin(rho)/(2*math.Pi)
+		if got := bivariateNormCDF(0, 0, rho); !approx(got, exp, 1e-9) {
+			t.Fatalf("M(0, 0, %v): expected %v, got %v", rho, exp, got)
+		}
+	}
+
+	if got, exp := bivariateNormCDF(0.5, -1.2, 0), normCDF(0.5)*normCDF(-1.2); !approx(got, exp, 1e-12) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+
+	// M(x, y, 1) = N(min(x, y)) and M(x, y, -1) = max(N(x) + N(y) - 1, 0)
+	if got, exp := bivariateNormCDF(0.3, 1.1, 1), normCDF(0.3); !approx(got, exp, 1e-9) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+	if got, exp := bivariateNormCDF(0.3, 1.1, -1), normCDF(0.3)+normCDF(1.1)-1; !approx(got, exp, 1e-9) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+}
+
+func TestBlackScholes(t *testing.T) {
+	in := Inputs{PutCall: td.PutCallCall, Underlying: 100, Strike: 100, Years: 1, Rate: 0.05, Volatility: 0.2}
+	call, err := BlackScholes(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := Greeks{Value: 10.4506, Delta: 0.6368, Gamma: 0.018762, Theta: -6.4140 / 365, Vega: 0.3752, Rho: 0.5323}
+	checkGreeks(t, "call", call, exp, 1e-4)
+
+	in.PutCall = td.PutCallPut
+	put, err := BlackScholes(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp = Greeks{Value: 5.5735, Delta: -0.3632, Gamma: 0.018762, Theta: -1.6579 / 365, Vega: 0.3752, Rho: -0.4189}
+	checkGreeks(t, "put", put, exp, 1e-4)
+
+	// put-call parity with dividends
+	in = Inputs{PutCall: td.PutCallCall, Underlying: 80, Strike: 85, Years: 0.25, Rate: 0.01, DividendYield: 0.02, Volatility: 0.45}
+	c, _ := Value(in, European)
+	in.PutCall = td.PutCallPut
+	p, _ := Value(in, European)
+	if parity := 80*math.Exp(-0.02*0.25) - 85*math.Exp(-0.01*0.25); !approx(c-p, parity, 1e-9) {
+		t.Fatalf("put-call parity: expected %v, got %v", parity, c-p)
+	}
+}
+
+func checkGreeks(t *testing.T, name string, got, exp Greeks, tol float64) {
+	t.Helper()
+	if !approx(got.Value, exp.Value, tol) || !approx(got.Delta, exp.Delta, tol) || !approx(got.Gamma, exp.Gamma, tol) ||
+		!approx(got.Theta, exp.Theta, tol) || !approx(got.Vega, exp.Vega, tol) || !approx(got.Rho, exp.Rho, tol) {
+		t.Fatalf("%s: expected %+v, got %+v", name, exp, got)
+	}
+}
+
+func TestBjerksundStensland(t *testing.T) {
+	tests := []Inputs{
+		{PutCall: td.PutCallPut, Underlying: 100, Strike: 100, Years: 1, Rate: 0.05, Volatility: 0.2},
+		{PutCall: td.PutCallPut, Underlying: 90, Strike: 100, Years: 0.5, Rate: 0.08, Volatility: 0.3},
+		{PutCall: td.PutCallPut, Underlying: 110, Strike: 100, Years: 0.1, Rate: 0.02, DividendYield: 0.01, Volatility: 0.5},
+		{PutCall: td.PutCallCall, Underlying: 100, Strike: 90, Years: 0.5, Rate: 0.03, DividendYield: 0.08, Volatility: 0.25},
+		{PutCall: td.PutCallCall, Underlying: 42, Strike: 40, Years: 0.75, Rate: 0.04, DividendYield: 0.06, Volatility: 0.35},
+	}
+
+	for _, in := range tests {
+		g, err := BjerksundStensland(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the approximation is a lower bound within ~1.5% of the tree
+		ref := binomial(in, 1000)
+		if g.Value > ref+0.01 || g.Value < ref*0.985 {
+			t.Fatalf("%+v: expected ~%v, got %v", in, ref, g.Value)
+		}
+		euro, _ := Value(in, European)
+		if g.Value < euro || g.Value < in.intrinsic() {
+			t.Fatalf("%+v: %v is below the European value %v or intrinsic value", in, g.Value, euro)
+		}
+
+		// finite difference Greeks against the tree
+		h := in.Underlying * 0.01
+		up, down := in, in
+		up.Underlying += h
+		down.Underlying -= h
+		if delta := (binomial(up, 1000) - binomial(down, 1000)) / (2 * h); !approx(g.Delta, delta, 0.01) {
+			t.Fatalf("%+v: expected delta ~%v, got %v", in, delta, g.Delta)
+		}
+		if g.Gamma <= 0 || g.Vega <= 0 || g.Theta >= 0 {
+			t.Fatalf("%+v: unexpected Greeks %+v", in, g)
+		}
+	}
+
+	// reference values from Haug, The Complete Guide to Option Pricing Formulas: K=100, T=0.1, r=0.1, b=0, v=0.15
+	for s, exp := range map[float64]float64{90: 0.0205, 100: 1.8757, 110: 10} {
+		in := Inputs{PutCall: td.PutCallCall, Underlying: s, Strike: 100, Years: 0.1, Rate: 0.1, DividendYield: 0.1, Volatility: 0.15}
+		if v, _ := Value(in, American); !approx(v, exp, 1e-4) {
+			t.Fatalf("S=%v: expected %v, got %v", s, exp, v)
+		}
+	}
+
+	// calls without dividends are never exercised early
+	in := Inputs{PutCall: td.PutCallCall, Underlying: 100, Strike: 100, Years: 1, Rate: 0.05, Volatility: 0.2}
+	a, _ := BjerksundStensland(in)
+	e, _ := BlackScholes(in)
+	if a != e {
+		t.Fatalf("expected %+v, got %+v", e, a)
+	}
+}
+
+func TestExpired(t *testing.T) {
+	for _, style := range []Style{American, European} {
+		g, err := Price(Inputs{PutCall: td.PutCallPut, Underlying: 95, Strike: 100}, style)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g != (Greeks{Value: 5, Delta: -1}) {
+			t.Fatalf("%v: unexpected %+v", style, g)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []Inputs{
+		{Underlying: 100, Strike: 100, Years: 1, Volatility: 0.2},
+		{PutCall: td.PutCallCall, Strike: 100, Years: 1, Volatility: 0.2},
+		{PutCall: td.PutCallCall, Underlying: 100, Strike: math.NaN(), Years: 1, Volatility: 0.2},
+		{PutCall: td.PutCallCall, Underlying: 100, Strike: 100, Years: -1, Volatility: 0.2},
+		{PutCall: td.PutCallCall, Underlying: 100, Strike: 100, Years: 1},
+		{PutCall: td.PutCallCall, Underlying: 100, Strike: 100, Years: 1, Volatility: 0.2, Rate: math.NaN()},
+	}
+	for _, in := range tests {
+		if _, err := Price(in, American); !errors.Is(err, ErrInvalidInputs) {
+			t.Fatalf("%+v: expected ErrInvalidInputs, got %v", in, err)
+		}
+	}
+}
+
+func TestFillGreeks(t *testing.T) {
+	now := time.Date(2020, 8, 14, 14, 0, 0, 0, time.UTC)
+	exp := now.AddDate(0, 0, 30)
+	opt := func(pc td.PutCall, strike, vol, delta float64) *td.Option {
+		return &td.Option{
+			PutCall:        string(pc),
+			Symbol:         td.OptionSymbol{Underlying: "AMD", Expiration: exp, PutCall: pc, Strike: strike}.String(),
+			ExpirationDate: exp.UnixNano() / int64(time.Millisecond),
+			StrikePrice:    strike,
+			Volatility:     vol,
+			Delta:          delta,
+			Gamma:          0.05,
+			Theta:          -0.1,
+			Vega:           0.1,
+			Rho:            0.01,
+		}
+	}
+
+	withQuotes := opt(td.PutCallCall, 80, 40, 0.55)
+	nan := opt(td.PutCallCall, 85, 40, math.NaN())
+	noQuotes := &td.Option{PutCall: "PUT", Symbol: "AMD_091320P75", StrikePrice: 75, DaysToExpiration: 30, Volatility: 35}
+	noVol := &td.Option{PutCall: "PUT", Symbol: "AMD_091320P70", StrikePrice: 70, DaysToExpiration: 30, Volatility: -999}
+
+	oc := &td.OptionChain{
+		UnderlyingPrice: 80,
+		InterestRate:    0.1,
+		CallExpDateMap: td.CallExpDateMap{
+			"2020-09-13:30": {"80.0": {withQuotes}, "85.0": {nan}},
+		},
+		PutExpDateMap: td.PutExpDateMap{
+			"2020-09-13:30": {"75.0": {noQuotes}, "70.0": {noVol}},
+		},
+	}
+
+	if n := FillGreeks(oc, &FillParams{Now: now}); n != 2 {
+		t.Fatalf("expected 2 filled options, got %d", n)
+	}
+
+	if withQuotes.Delta != 0.55 || withQuotes.TheoreticalOptionValue != 0 {
+		t.Fatalf("valid Greeks were overwritten: %+v", withQuotes)
+	}
+
+	in := Inputs{PutCall: td.PutCallCall, Underlying: 80, Strike: 85, Years: 30.0 / 365, Rate: 0.001, Volatility: 0.4}
+	g, _ := BlackScholes(in)
+	if !approx(nan.Delta, g.Delta, 1e-9) || nan.Gamma != 0.05 || !approx(nan.TheoreticalOptionValue, g.Value, 1e-9) {
+		t.Fatalf("expected only the NaN delta and the value to be filled, got %+v", nan)
+	}
+
+	if !(noQuotes.Delta < 0 && noQuotes.Gamma > 0 && noQuotes.Theta < 0 && noQuotes.TheoreticalOptionValue > 0) {
+		t.Fatalf("expected the missing Greeks to be filled, got %+v", noQuotes)
+	}
+
+	if noVol.Delta != 0 {
+		t.Fatalf("expected the option without a volatility to be skipped, got %+v", noVol)
+	}
+}
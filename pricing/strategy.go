@@ -0,0 +1,312 @@
+package pricing
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"go.oneofone.dev/td"
+)
+
+const (
+	strategyGridSize = 1000
+	breakevenTol     = 1e-9
+)
+
+// Leg is a position in an option or in the underlying stock.
+type Leg struct {
+	// Option is nil for stock legs.
+	Option *td.Option
+
+	// Quantity is the number of contracts or shares, negative for short positions.
+	Quantity int
+
+	// Price is the entry price per share.
+	Price float64
+
+	// Volatility is used to price the option before its expiration.
+	Volatility float64
+}
+
+// IsStock returns true if l is a stock leg.
+func (l *Leg) IsStock() bool { return l.Option == nil }
+
+func (l *Leg) multiplier() float64 {
+	if l.IsStock() {
+		return 1
+	}
+	return l.Option.ContractMultiplier()
+}
+
+// Strategy is a multi-leg position on a single underlying.
+type Strategy struct {
+	Underlying    float64
+	Rate          float64
+	DividendYield float64
+	Style         Style
+
+	// Now is the entry time, the implied volatilities and the probability of profit are computed at Now.
+	Now time.Time
+
+	Legs []*Leg
+
+	oc *td.OptionChain
+}
+
+// NewStrategy returns an empty strategy on the underlying of oc, priced as American options at time.Now()
+// with the chain's interest rate. Set DividendYield, Style or Now before adding legs if needed.
+func NewStrategy(oc *td.OptionChain) *Strategy {
+	s := &Strategy{
+		Underlying: UnderlyingPrice(oc),
+		Now:        time.Now(),
+		oc:         oc,
+	}
+	if td.ValidGreek(oc.InterestRate) {
+		s.Rate = oc.InterestRate / 100
+	}
+	return s
+}
+
+// AddOption adds qty contracts of o (negative to sell) at its mark, the leg's volatility is implied from the mark,
+// falling back to TD's volatility.
+func (s *Strategy) AddOption(o *td.Option, qty int) *Leg {
+	l := &Leg{Option: o, Quantity: qty, Price: optionMark(o)}
+
+	if s.oc != nil {
+		iv, err := OptionIV(s.oc, o, &IVParams{Style: s.Style, DividendYield: s.DividendYield, Now: s.Now})
+		if err == nil {
+			l.Volatility = iv.Mark
+		} else {
+			l.Volatility = optionInputs(s.oc, o, s.Now).Volatility
+		}
+	} else if td.ValidGreek(o.Volatility) && o.Volatility > 0 {
+		l.Volatility = o.Volatility / 100
+	}
+	if !(l.Volatility > 0) {
+		l.Volatility = defaultIVGuess
+	}
+
+	s.Legs = append(s.Legs, l)
+	return l
+}
+
+// AddStock adds qty shares (negative to short) at the underlying price.
+func (s *Strategy) AddStock(qty int) *Leg {
+	l := &Leg{Quantity: qty, Price: s.Underlying}
+	s.Legs = append(s.Legs, l)
+	return l
+}
+
+// Cost returns the entry cost of the position, negative for a credit.
+func (s *Strategy) Cost() (cost float64) {
+	for _, l := range s.Legs {
+		cost += float64(l.Quantity) * l.Price * l.multiplier()
+	}
+	return
+}
+
+// Expiration returns the first expiration of the option legs, Now if there are none.
+func (s *Strategy) Expiration() time.Time {
+	var exp time.Time
+	for _, l := range s.Legs {
+		if l.IsStock() {
+			continue
+		}
+		if e := l.Option.Expiration(); exp.IsZero() || e.Before(exp) {
+			exp = e
+		}
+	}
+	if exp.IsZero() {
+		return s.Now
+	}
+	return exp
+}
+
+// Value returns the value of the position if the underlying is at price at the given time,
+// options are worth their intrinsic value once expired and their model value before.
+func (s *Strategy) Value(price float64, at time.Time) (v float64) {
+	price = math.Max(price, 0)
+	for _, l := range s.Legs {
+		if l.IsStock() {
+			v += float64(l.Quantity) * price
+			continue
+		}
+		in := Inputs{
+			PutCall:       td.PutCall(l.Option.PutCall),
+			Underlying:    math.Max(price, 1e-9),
+			Strike:        l.Option.StrikePrice,
+			Years:         YearsToExpiration(l.Option.Expiration(), at),
+			Rate:          s.Rate,
+			DividendYield: s.DividendYield,
+			Volatility:    l.Volatility,
+		}
+		v += float64(l.Quantity) * value(&in, s.Style) * l.multiplier()
+	}
+	return
+}
+
+// PnL returns the profit or loss of the position if the underlying is at price at the given time.
+func (s *Strategy) PnL(price float64, at time.Time) float64 {
+	return s.Value(price, at) - s.Cost()
+}
+
+// Payoff returns the profit or loss at the first expiration, later legs are valued with the model.
+func (s *Strategy) Payoff(price float64) float64 {
+	return s.PnL(price, s.Expiration())
+}
+
+// priceGrid returns the prices the payoff is evaluated at: from 0 to 3 times the highest strike or underlying
+// price, including every strike.
+func (s *Strategy) priceGrid() []float64 {
+	hi := s.Underlying
+	var strikes []float64
+	for _, l := range s.Legs {
+		if !l.IsStock() {
+			strikes = append(strikes, l.Option.StrikePrice)
+			hi = math.Max(hi, l.Option.StrikePrice)
+		}
+	}
+	hi *= 3
+
+	grid := make([]float64, 0, strategyGridSize+1+len(strikes))
+	for i := 0; i <= strategyGridSize; i++ {
+		grid = append(grid, hi*float64(i)/strategyGridSize)
+	}
+	grid = append(grid, strikes...)
+	sort.Float64s(grid)
+	return grid
+}
+
+// Breakevens returns the underlying prices at the first expiration where the position goes from a loss to
+// a profit or back.
+func (s *Strategy) Breakevens() (out []float64) {
+	if len(s.Legs) == 0 {
+		return
+	}
+	exp := s.Expiration()
+	grid := s.priceGrid()
+	profit := func(p float64) bool { return s.PnL(p, exp) >= -breakevenTol }
+
+	prevPrice, prev := grid[0], profit(grid[0])
+	for _, p := range grid[1:] {
+		cur := profit(p)
+		if cur != prev {
+			lo, hi := prevPrice, p
+			for i := 0; i < 100 && hi-lo > breakevenTol; i++ {
+				if mid := (lo + hi) / 2; profit(mid) == prev {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			out = append(out, (lo+hi)/2)
+		}
+		prevPrice, prev = p, cur
+	}
+	return
+}
+
+// MaxProfit returns the max profit at the first expiration, +Inf if it's unlimited.
+func (s *Strategy) MaxProfit() float64 {
+	if s.tailSlope() > 0 {
+		return math.Inf(1)
+	}
+	return s.extreme(func(a, b float64) bool { return a > b })
+}
+
+// MaxLoss returns the max loss at the first expiration as a negative number, -Inf if it's unlimited.
+func (s *Strategy) MaxLoss() float64 {
+	if s.tailSlope() < 0 {
+		return math.Inf(-1)
+	}
+	return s.extreme(func(a, b float64) bool { return a < b })
+}
+
+// tailSlope returns the slope of the payoff above the price grid, below it the payoff is bounded by the price of 0.
+func (s *Strategy) tailSlope() float64 {
+	if len(s.Legs) == 0 {
+		return 0
+	}
+	grid := s.priceGrid()
+	top := grid[len(grid)-1]
+	exp := s.Expiration()
+	if slope := (s.PnL(top*2, exp) - s.PnL(top, exp)) / top; math.Abs(slope) >= 1e-6 {
+		return slope
+	}
+	return 0
+}
+
+func (s *Strategy) extreme(better func(a, b float64) bool) float64 {
+	if len(s.Legs) == 0 {
+		return 0
+	}
+	exp := s.Expiration()
+	grid := s.priceGrid()
+	best := s.PnL(grid[0], exp)
+	for _, p := range grid[1:] {
+		if v := s.PnL(p, exp); better(v, best) {
+			best = v
+		}
+	}
+	return best
+}
+
+// ProbabilityOfProfit returns the probability that the position is profitable at the first expiration,
+// assuming a lognormal underlying with the risk neutral drift and the given volatility.
+// If vol is 0, the average volatility of the option legs is used.
+func (s *Strategy) ProbabilityOfProfit(vol float64) float64 {
+	if len(s.Legs) == 0 {
+		return 0
+	}
+
+	exp := s.Expiration()
+	t := YearsToExpiration(exp, s.Now)
+	if !(vol > 0) {
+		var n float64
+		for _, l := range s.Legs {
+			if !l.IsStock() {
+				vol += l.Volatility
+				n++
+			}
+		}
+		if n == 0 {
+			vol = defaultIVGuess
+		} else {
+			vol /= n
+		}
+	}
+
+	if t <= 0 || !(s.Underlying > 0) {
+		if s.PnL(s.Underlying, exp) > 0 {
+			return 1
+		}
+		return 0
+	}
+
+	cdf := func(x float64) float64 {
+		switch {
+		case x <= 0:
+			return 0
+		case math.IsInf(x, 1):
+			return 1
+		}
+		vt := vol * math.Sqrt(t)
+		return normCDF((math.Log(x/s.Underlying) - (s.Rate-s.DividendYield-vol*vol/2)*t) / vt)
+	}
+
+	bounds := append([]float64{0}, s.Breakevens()...)
+	bounds = append(bounds, math.Inf(1))
+
+	var p float64
+	for i := 1; i < len(bounds); i++ {
+		a, b := bounds[i-1], bounds[i]
+		mid := (a + b) / 2
+		if math.IsInf(b, 1) {
+			mid = a*1.1 + 1
+		}
+		if s.PnL(mid, exp) > 0 {
+			p += cdf(b) - cdf(a)
+		}
+	}
+	return p
+}
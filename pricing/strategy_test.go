@@ -0,0 +1,157 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+
+	"go.oneofone.dev/td"
+)
+
+// chainOption returns the option of the test chain with the given put/call, strike and days to expiration.
+func chainOption(oc *td.OptionChain, pc td.PutCall, strike float64, dte int) *td.Option {
+	for _, o := range oc.Options() {
+		if td.PutCall(o.PutCall) == pc && o.StrikePrice == strike && o.DaysToExpiration == dte {
+			return o
+		}
+	}
+	panic("missing option")
+}
+
+func newTestStrategy(oc *td.OptionChain) *Strategy {
+	s := NewStrategy(oc)
+	s.Now = testNow
+	return s
+}
+
+func TestStrategyVertical(t *testing.T) {
+	oc := testChain()
+	s := newTestStrategy(oc)
+	long, short := chainOption(oc, td.PutCallCall, 95, 30), chainOption(oc, td.PutCallCall, 105, 30)
+	s.AddOption(long, 1)
+	s.AddOption(short, -1)
+
+	debit := (long.Mark - short.Mark) * 100
+	if !approx(s.Cost(), debit, 1e-9) {
+		t.Fatalf("expected a %v debit, got %v", debit, s.Cost())
+	}
+
+	// the legs' volatilities are implied from the marks, so the position is worth its cost right away
+	if pnl := s.PnL(100, testNow); !approx(pnl, 0, 1e-3) {
+		t.Fatalf("expected no P&L at entry, got %v", pnl)
+	}
+	if !approx(s.Legs[0].Volatility, testSmile(95, YearsToExpiration(long.Expiration(), testNow)), 1e-4) {
+		t.Fatalf("unexpected leg volatility: %v", s.Legs[0].Volatility)
+	}
+
+	if p := s.Payoff(90); !approx(p, -debit, 1e-9) {
+		t.Fatalf("expected a %v loss below the strikes, got %v", -debit, p)
+	}
+	if p := s.Payoff(120); !approx(p, 1000-debit, 1e-9) {
+		t.Fatalf("expected a %v profit above the strikes, got %v", 1000-debit, p)
+	}
+
+	be := s.Breakevens()
+	if len(be) != 1 || !approx(be[0], 95+debit/100, 1e-6) {
+		t.Fatalf("expected a breakeven at %v, got %v", 95+debit/100, be)
+	}
+	if mp, ml := s.MaxProfit(), s.MaxLoss(); !approx(mp, 1000-debit, 1e-9) || !approx(ml, -debit, 1e-9) {
+		t.Fatalf("unexpected max profit %v or loss %v", mp, ml)
+	}
+
+	// above the strikes, the spread converges to its max value as time passes
+	week := testNow.AddDate(0, 0, 7)
+	if now, later := s.PnL(120, testNow), s.PnL(120, week); !(now < later && later < s.Payoff(120)) {
+		t.Fatalf("unexpected P&L %v then %v", now, later)
+	}
+
+	vol := 0.3
+	years := YearsToExpiration(s.Expiration(), testNow)
+	d := (math.Log(be[0]/100) - (s.Rate-vol*vol/2)*years) / (vol * math.Sqrt(years))
+	if pop := s.ProbabilityOfProfit(vol); !approx(pop, 1-normCDF(d), 1e-6) {
+		t.Fatalf("expected a %v probability of profit, got %v", 1-normCDF(d), pop)
+	}
+	if pop := s.ProbabilityOfProfit(0); !(pop > 0 && pop < 1) {
+		t.Fatalf("unexpected probability of profit %v", pop)
+	}
+}
+
+func TestStrategyLimits(t *testing.T) {
+	oc := testChain()
+	call, put := chainOption(oc, td.PutCallCall, 105, 30), chainOption(oc, td.PutCallPut, 95, 30)
+
+	s := newTestStrategy(oc)
+	s.AddOption(call, 1)
+	if !math.IsInf(s.MaxProfit(), 1) || !approx(s.MaxLoss(), -call.Mark*100, 1e-9) {
+		t.Fatalf("long call: unexpected max profit %v or loss %v", s.MaxProfit(), s.MaxLoss())
+	}
+
+	s = newTestStrategy(oc)
+	s.AddOption(call, -1)
+	if !math.IsInf(s.MaxLoss(), -1) || !approx(s.MaxProfit(), call.Mark*100, 1e-9) {
+		t.Fatalf("short call: unexpected max profit %v or loss %v", s.MaxProfit(), s.MaxLoss())
+	}
+
+	// covered call: capped profit, the loss is bounded by the stock going to 0
+	s = newTestStrategy(oc)
+	s.AddStock(100)
+	s.AddOption(call, -1)
+	if mp, ml := s.MaxProfit(), s.MaxLoss(); !approx(mp, 500+call.Mark*100, 1e-9) || !approx(ml, -10000+call.Mark*100, 1e-9) {
+		t.Fatalf("covered call: unexpected max profit %v or loss %v", mp, ml)
+	}
+	if be := s.Breakevens(); len(be) != 1 || !approx(be[0], 100-call.Mark, 1e-6) {
+		t.Fatalf("covered call: unexpected breakevens %v", be)
+	}
+
+	// short strangle: two breakevens, profitable between them
+	s = newTestStrategy(oc)
+	s.AddOption(put, -1)
+	s.AddOption(call, -1)
+	credit := put.Mark + call.Mark
+	be := s.Breakevens()
+	if len(be) != 2 || !approx(be[0], 95-credit, 1e-6) || !approx(be[1], 105+credit, 1e-6) {
+		t.Fatalf("strangle: unexpected breakevens %v", be)
+	}
+	if pop := s.ProbabilityOfProfit(0.3); !(pop > 0.5 && pop < 1) {
+		t.Fatalf("strangle: unexpected probability of profit %v", pop)
+	}
+
+	// the contract multiplier comes from the option
+	mini := *call
+	mini.Multiplier = 10
+	s = newTestStrategy(oc)
+	s.AddOption(&mini, 1)
+	if !approx(s.Cost(), call.Mark*10, 1e-9) {
+		t.Fatalf("mini option: expected a %v cost, got %v", call.Mark*10, s.Cost())
+	}
+
+	if s := newTestStrategy(oc); s.MaxProfit() != 0 || s.MaxLoss() != 0 || s.Breakevens() != nil || s.ProbabilityOfProfit(0.3) != 0 {
+		t.Fatal("expected zeros for an empty strategy")
+	}
+}
+
+func TestStrategyCalendar(t *testing.T) {
+	oc := testChain()
+	s := newTestStrategy(oc)
+	front, back := chainOption(oc, td.PutCallCall, 100, 30), chainOption(oc, td.PutCallCall, 100, 90)
+	s.AddOption(front, -1)
+	s.AddOption(back, 1)
+
+	if !s.Expiration().Equal(front.Expiration()) {
+		t.Fatalf("expected the front month expiration, got %v", s.Expiration())
+	}
+
+	// the back month is still worth its time value at the front expiration
+	debit := (back.Mark - front.Mark) * 100
+	if p := s.Payoff(100); !(p > 0) {
+		t.Fatalf("expected a profit at the strike, got %v", p)
+	}
+	if be := s.Breakevens(); len(be) != 2 || !(be[0] < 100 && be[1] > 100) {
+		t.Fatalf("unexpected breakevens %v", be)
+	}
+	if ml := s.MaxLoss(); !(ml < 0 && ml >= -debit-1e-6) {
+		t.Fatalf("expected the loss to be limited to the %v debit, got %v", debit, ml)
+	}
+	if mp := s.MaxProfit(); math.IsInf(mp, 0) || !approx(mp, s.Payoff(100), 1e-9) {
+		t.Fatalf("expected the max profit at the strike, got %v", mp)
+	}
+}
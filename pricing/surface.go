@@ -0,0 +1,256 @@
+package pricing
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.oneofone.dev/td"
+)
+
+var ErrEmptySurface = errors.New("no implied volatilities in the chain")
+
+// maxSkewMoneyness limits the skew fit to strikes within ±20% of the underlying (in log moneyness).
+const maxSkewMoneyness = 0.2
+
+// SurfaceParams controls NewVolSurface.
+type SurfaceParams struct {
+	IVParams
+
+	// PutCall limits the surface to calls or puts, by default out of the money options are used:
+	// puts below the underlying price and calls above it, falling back to the other side when missing.
+	PutCall td.PutCall
+}
+
+// VolSurface is a strike × expiration grid of implied volatilities built from the marks of a chain.
+type VolSurface struct {
+	Underlying float64
+	Now        time.Time
+
+	Expirations []time.Time
+	Strikes     []float64
+
+	// IV is indexed by expiration then strike, NaN where the chain has no usable quote.
+	IV [][]float64
+
+	years []float64
+}
+
+// NewVolSurface solves the implied volatility of every option in oc and builds the surface,
+// expirations without any solvable option are left out.
+func NewVolSurface(oc *td.OptionChain, p *SurfaceParams) (*VolSurface, error) {
+	var sp SurfaceParams
+	if p != nil {
+		sp = *p
+	}
+	sp.IVParams = sp.IVParams.orDefault()
+
+	s := &VolSurface{Underlying: UnderlyingPrice(oc), Now: sp.Now}
+	if !(s.Underlying > 0) {
+		return nil, ErrEmptySurface
+	}
+
+	type point struct {
+		iv  float64
+		otm bool
+	}
+	byExp := map[int64]map[float64]point{}
+	exps := map[int64]time.Time{}
+	strikes := map[float64]bool{}
+
+	for _, o := range oc.Options() {
+		pc := td.PutCall(o.PutCall)
+		if sp.PutCall != "" && pc != sp.PutCall {
+			continue
+		}
+		iv, err := OptionIV(oc, o, &sp.IVParams)
+		if err != nil {
+			continue
+		}
+
+		otm := (pc == td.PutCallPut) == (o.StrikePrice < s.Underlying)
+		pts := byExp[o.ExpirationDate]
+		if pts == nil {
+			pts = map[float64]point{}
+			byExp[o.ExpirationDate] = pts
+			exps[o.ExpirationDate] = o.Expiration()
+		}
+		if old, ok := pts[o.StrikePrice]; !ok || (otm && !old.otm) {
+			pts[o.StrikePrice] = point{iv.Mark, otm}
+		}
+		strikes[o.StrikePrice] = true
+	}
+
+	if len(byExp) == 0 {
+		return nil, ErrEmptySurface
+	}
+
+	for k := range strikes {
+		s.Strikes = append(s.Strikes, k)
+	}
+	sort.Float64s(s.Strikes)
+
+	keys := make([]int64, 0, len(byExp))
+	for k := range byExp {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	for _, k := range keys {
+		exp := exps[k]
+		row := make([]float64, len(s.Strikes))
+		for i, strike := range s.Strikes {
+			row[i] = math.NaN()
+			if pt, ok := byExp[k][strike]; ok {
+				row[i] = pt.iv
+			}
+		}
+		s.Expirations = append(s.Expirations, exp)
+		s.IV = append(s.IV, row)
+		s.years = append(s.years, YearsToExpiration(exp, s.Now))
+	}
+	return s, nil
+}
+
+// smile interpolates the volatility of expiration i linearly in strike, flat outside of the quoted strikes.
+func (s *VolSurface) smile(i int, strike float64) float64 {
+	row := s.IV[i]
+	lo, hi := -1, -1
+	for j, k := range s.Strikes {
+		if math.IsNaN(row[j]) {
+			continue
+		}
+		if k <= strike {
+			lo = j
+		}
+		if k >= strike {
+			hi = j
+			break
+		}
+	}
+
+	switch {
+	case lo == -1 && hi == -1:
+		return math.NaN()
+	case lo == -1:
+		return row[hi]
+	case hi == -1, lo == hi:
+		return row[lo]
+	}
+	k0, k1 := s.Strikes[lo], s.Strikes[hi]
+	return row[lo] + (row[hi]-row[lo])*(strike-k0)/(k1-k0)
+}
+
+// Vol returns the interpolated volatility at strike and expiration: linear in strike and linear in
+// total variance between expirations, flat outside of the surface.
+func (s *VolSurface) Vol(strike float64, expiration time.Time) float64 {
+	if len(s.Expirations) == 0 {
+		return math.NaN()
+	}
+
+	t := YearsToExpiration(expiration, s.Now)
+	n := len(s.years)
+	i := sort.SearchFloat64s(s.years, t)
+	switch {
+	case i == 0:
+		return s.smile(0, strike)
+	case i == n:
+		return s.smile(n-1, strike)
+	case s.years[i] == t:
+		return s.smile(i, strike)
+	}
+
+	t0, t1 := s.years[i-1], s.years[i]
+	v0, v1 := s.smile(i-1, strike), s.smile(i, strike)
+	if t0 <= 0 {
+		return v1
+	}
+	w := v0*v0*t0 + (v1*v1*t1-v0*v0*t0)*(t-t0)/(t1-t0)
+	return math.Sqrt(w / t)
+}
+
+// TermPoint is the at the money volatility of an expiration.
+type TermPoint struct {
+	Expiration time.Time
+	Years      float64
+	ATM        float64
+}
+
+// TermStructure returns the at the money volatility of every expiration, interpolated at the underlying price.
+func (s *VolSurface) TermStructure() []TermPoint {
+	out := make([]TermPoint, len(s.Expirations))
+	for i, exp := range s.Expirations {
+		out[i] = TermPoint{Expiration: exp, Years: s.years[i], ATM: s.smile(i, s.Underlying)}
+	}
+	return out
+}
+
+// Skew is the volatility smile of an expiration.
+type Skew struct {
+	Expiration time.Time
+	ATM        float64
+
+	// Slope is the least squares slope of the volatility against ln(strike / underlying) for strikes
+	// within 20% of the underlying, it's negative when puts are richer than calls.
+	Slope float64
+
+	// Strikes and their volatilities, without the strikes missing a quote.
+	Strikes []float64
+	IV      []float64
+}
+
+// Skews returns the smile of every expiration.
+func (s *VolSurface) Skews() []Skew {
+	out := make([]Skew, len(s.Expirations))
+	for i, exp := range s.Expirations {
+		sk := Skew{Expiration: exp, ATM: s.smile(i, s.Underlying), Slope: math.NaN()}
+
+		var n, sx, sy, sxx, sxy float64
+		for j, k := range s.Strikes {
+			iv := s.IV[i][j]
+			if math.IsNaN(iv) {
+				continue
+			}
+			sk.Strikes = append(sk.Strikes, k)
+			sk.IV = append(sk.IV, iv)
+
+			if x := math.Log(k / s.Underlying); math.Abs(x) <= maxSkewMoneyness {
+				n, sx, sy, sxx, sxy = n+1, sx+x, sy+iv, sxx+x*x, sxy+x*iv
+			}
+		}
+		if d := n*sxx - sx*sx; n >= 2 && d > 0 {
+			sk.Slope = (n*sxy - sx*sy) / d
+		}
+		out[i] = sk
+	}
+	return out
+}
+
+// WriteCSV writes the quoted points of the surface in long format:
+// expiration (2006-01-02), years, strike, moneyness (strike / underlying) and iv.
+func (s *VolSurface) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"expiration", "years", "strike", "moneyness", "iv"}); err != nil {
+		return err
+	}
+
+	ff := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	for i, exp := range s.Expirations {
+		for j, k := range s.Strikes {
+			iv := s.IV[i][j]
+			if math.IsNaN(iv) {
+				continue
+			}
+			rec := []string{exp.Format("2006-01-02"), ff(s.years[i]), ff(k), ff(k / s.Underlying), ff(iv)}
+			if err := cw.Write(rec); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
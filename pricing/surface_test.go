@@ -0,0 +1,192 @@
+package pricing
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.oneofone.dev/td"
+)
+
+var testNow = time.Date(2020, 8, 14, 14, 0, 0, 0, time.UTC)
+
+// testSmile is the volatility used to price the test chain, put skew plus an upward term structure.
+func testSmile(strike, years float64) float64 {
+	return 0.3 - 0.2*math.Log(strike/100) + 0.1*years
+}
+
+// testChain returns a chain priced with testSmile, marks are the American values and TD's volatility is wrong on purpose.
+func testChain() *td.OptionChain {
+	oc := &td.OptionChain{
+		Symbol:          "XYZ",
+		UnderlyingPrice: 100,
+		InterestRate:    1,
+		CallExpDateMap:  td.CallExpDateMap{},
+		PutExpDateMap:   td.PutExpDateMap{},
+	}
+
+	for _, days := range []int{30, 90} {
+		exp := testNow.AddDate(0, 0, days)
+		key := exp.Format("2006-01-02") + ":" + strconv.Itoa(days)
+		oc.CallExpDateMap[key] = map[td.Strike][]*td.Option{}
+		oc.PutExpDateMap[key] = map[td.Strike][]*td.Option{}
+
+		for strike := 80.0; strike <= 120; strike += 5 {
+			for _, pc := range []td.PutCall{td.PutCallCall, td.PutCallPut} {
+				in := Inputs{PutCall: pc, Underlying: 100, Strike: strike, Years: YearsToExpiration(exp, testNow), Rate: 0.01}
+				in.Volatility = testSmile(strike, in.Years)
+				g, _ := Price(in, American)
+				o := &td.Option{
+					PutCall:          string(pc),
+					Symbol:           td.OptionSymbol{Underlying: "XYZ", Expiration: exp, PutCall: pc, Strike: strike}.String(),
+					ExpirationDate:   exp.UnixNano() / int64(time.Millisecond),
+					DaysToExpiration: days,
+					StrikePrice:      strike,
+					Volatility:       -999,
+					Bid:              math.Max(g.Value-0.05, 0),
+					Ask:              g.Value + 0.05,
+					Mark:             g.Value,
+					Delta:            g.Delta,
+					Gamma:            g.Gamma,
+					OpenInterest:     int(1000 - 10*math.Abs(strike-100)),
+					TotalVolume:      int(500 - 10*math.Abs(strike-100)),
+				}
+				sk := td.Strike(strconv.FormatFloat(strike, 'f', 1, 64))
+				if pc == td.PutCallCall {
+					oc.CallExpDateMap[key][sk] = []*td.Option{o}
+				} else {
+					oc.PutExpDateMap[key][sk] = []*td.Option{o}
+				}
+			}
+		}
+	}
+	return oc
+}
+
+func TestImpliedVol(t *testing.T) {
+	for _, style := range []Style{European, American} {
+		for _, pc := range []td.PutCall{td.PutCallCall, td.PutCallPut} {
+			for _, strike := range []float64{70, 100, 130} {
+				in := Inputs{PutCall: pc, Underlying: 100, Strike: strike, Years: 0.5, Rate: 0.03, DividendYield: 0.01, Volatility: 0.45}
+				price, _ := Value(in, style)
+				in.Volatility = 0
+				iv, err := ImpliedVol(in, style, price)
+				if err != nil {
+					t.Fatalf("%v %v %v: %v", style, pc, strike, err)
+				}
+				if !approx(iv, 0.45, 1e-4) {
+					t.Fatalf("%v %v %v: expected 0.45, got %v", style, pc, strike, iv)
+				}
+			}
+		}
+	}
+
+	in := Inputs{PutCall: td.PutCallPut, Underlying: 80, Strike: 100, Years: 0.5, Rate: 0.03}
+	if _, err := ImpliedVol(in, American, 19); !errors.Is(err, ErrNoImpliedVol) {
+		t.Fatalf("expected ErrNoImpliedVol below intrinsic, got %v", err)
+	}
+	if _, err := ImpliedVol(in, American, 150); !errors.Is(err, ErrNoImpliedVol) {
+		t.Fatalf("expected ErrNoImpliedVol above the strike, got %v", err)
+	}
+}
+
+func TestOptionIV(t *testing.T) {
+	oc := testChain()
+	p := &IVParams{Now: testNow}
+	for _, o := range oc.Options() {
+		iv, err := OptionIV(oc, o, p)
+		if o.PutCall == "PUT" && o.StrikePrice-oc.UnderlyingPrice >= o.Mark-1e-6 {
+			// deep in the money American puts are worth their intrinsic value, the volatility is undefined
+			if !errors.Is(err, ErrNoImpliedVol) {
+				t.Fatalf("%s: expected ErrNoImpliedVol, got %v", o.Symbol, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := testSmile(o.StrikePrice, YearsToExpiration(o.Expiration(), testNow))
+		if !approx(iv.Mark, exp, 1e-4) {
+			t.Fatalf("%s: expected %v, got %v", o.Symbol, exp, iv.Mark)
+		}
+		if !(iv.Ask > iv.Mark) || !(math.IsNaN(iv.Bid) || iv.Bid < iv.Mark) {
+			t.Fatalf("%s: unexpected %+v", o.Symbol, iv)
+		}
+	}
+
+	if _, err := OptionIV(oc, &td.Option{PutCall: "CALL", Symbol: "XYZ_091320C100", StrikePrice: 100, DaysToExpiration: 30}, p); !errors.Is(err, ErrNoImpliedVol) {
+		t.Fatalf("expected ErrNoImpliedVol without a price, got %v", err)
+	}
+}
+
+func TestVolSurface(t *testing.T) {
+	oc := testChain()
+	s, err := NewVolSurface(oc, &SurfaceParams{IVParams: IVParams{Now: testNow}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Expirations) != 2 || len(s.Strikes) != 9 {
+		t.Fatalf("unexpected surface: %v × %v", s.Expirations, s.Strikes)
+	}
+
+	exp1, exp2 := s.Expirations[0], s.Expirations[1]
+	t1, t2 := YearsToExpiration(exp1, testNow), YearsToExpiration(exp2, testNow)
+
+	// on the grid, between strikes and outside the strikes
+	tests := []struct {
+		strike float64
+		exp    time.Time
+		want   float64
+	}{
+		{90, exp1, testSmile(90, t1)},
+		{110, exp2, testSmile(110, t2)},
+		{92.5, exp1, (testSmile(90, t1) + testSmile(95, t1)) / 2},
+		{60, exp2, testSmile(80, t2)},
+		{100, testNow.AddDate(1, 0, 0), testSmile(100, t2)},
+	}
+	for _, tc := range tests {
+		if got := s.Vol(tc.strike, tc.exp); !approx(got, tc.want, 1e-4) {
+			t.Fatalf("Vol(%v, %v): expected %v, got %v", tc.strike, tc.exp, tc.want, got)
+		}
+	}
+
+	// total variance is linear between expirations
+	mid := testNow.AddDate(0, 0, 60)
+	tm := YearsToExpiration(mid, testNow)
+	v1, v2 := testSmile(100, t1), testSmile(100, t2)
+	want := math.Sqrt((v1*v1*t1 + (v2*v2*t2-v1*v1*t1)*(tm-t1)/(t2-t1)) / tm)
+	if got := s.Vol(100, mid); !approx(got, want, 1e-4) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	ts := s.TermStructure()
+	if len(ts) != 2 || !approx(ts[0].ATM, v1, 1e-4) || !approx(ts[1].ATM, v2, 1e-4) {
+		t.Fatalf("unexpected term structure: %+v", ts)
+	}
+
+	for _, sk := range s.Skews() {
+		if len(sk.Strikes) != 9 || !approx(sk.Slope, -0.2, 1e-3) {
+			t.Fatalf("unexpected skew: %+v", sk)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := s.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	recs, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 19 || recs[0][0] != "expiration" || recs[1][0] != "2020-09-13" || recs[1][2] != "80" || recs[1][3] != "0.8" {
+		t.Fatalf("unexpected csv: %v", recs[:2])
+	}
+
+	if _, err := NewVolSurface(&td.OptionChain{UnderlyingPrice: 100}, nil); err != ErrEmptySurface {
+		t.Fatalf("expected ErrEmptySurface, got %v", err)
+	}
+}
@@ -0,0 +1,320 @@
+package td
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultQuoteWatchInterval = 2 * time.Second
+
+// QuoteEvent is a quote change, it has the same shape whether it comes from a QuoteWatcher or a streamer subscription.
+type QuoteEvent struct {
+	Symbol string
+
+	// Quote is the latest full quote.
+	Quote Quote
+
+	// Prev is the previous quote of the symbol, nil for the first event.
+	Prev Quote
+}
+
+// quoteChanged reports whether q differs from prev in its quote and trade times, prices or sizes.
+// Some asset types (mutual funds for example) have no times and streamed updates don't always move them,
+// so the prices and sizes are compared too.
+func quoteChanged(prev, q Quote) bool {
+	if prev == nil {
+		return true
+	}
+	pb, b := prev.Base(), q.Base()
+	return pb.QuoteTimeInLong != b.QuoteTimeInLong || pb.TradeTimeInLong != b.TradeTimeInLong ||
+		pb.LastPrice != b.LastPrice || pb.BidPrice != b.BidPrice || pb.AskPrice != b.AskPrice || pb.Mark != b.Mark ||
+		pb.LastSize != b.LastSize || pb.BidSize != b.BidSize || pb.AskSize != b.AskSize || pb.TotalVolume != b.TotalVolume
+}
+
+// QuoteWatcher polls quotes through Client.BatchQuotes for accounts without streamer access,
+// only quotes that changed since the last poll are delivered on C.
+type QuoteWatcher struct {
+	C <-chan *QuoteEvent
+
+	// OnError is called when a poll fails, some quotes may still be delivered if only part of a batch failed.
+	OnError func(err error)
+
+	c        *Client
+	interval time.Duration
+	ch       chan *QuoteEvent
+
+	mux     sync.Mutex
+	symbols []string
+	last    map[string]Quote
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewQuoteWatcher returns a watcher that polls symbols every interval, default is 2 seconds.
+// Set OnError if needed, then call Start.
+func (c *Client) NewQuoteWatcher(interval time.Duration, symbols ...string) *QuoteWatcher {
+	if interval <= 0 {
+		interval = defaultQuoteWatchInterval
+	}
+	ch := make(chan *QuoteEvent, defaultBufferSize)
+	w := &QuoteWatcher{
+		C:        ch,
+		c:        c,
+		interval: interval,
+		ch:       ch,
+		last:     map[string]Quote{},
+		done:     make(chan struct{}),
+	}
+	w.Add(symbols...)
+	return w
+}
+
+// Start starts polling until ctx is done or Close is called, C is closed once the watcher stops.
+func (w *QuoteWatcher) Start(ctx context.Context) {
+	w.startOnce.Do(func() { go w.run(ctx) })
+}
+
+// Add adds symbols to the watched set.
+func (w *QuoteWatcher) Add(symbols ...string) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	for _, sym := range splitKeys(strings.Join(symbols, ",")) {
+		if !containsString(w.symbols, sym) {
+			w.symbols = append(w.symbols, sym)
+		}
+	}
+}
+
+// Remove removes symbols from the watched set.
+func (w *QuoteWatcher) Remove(symbols ...string) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	out := w.symbols[:0]
+	for _, sym := range w.symbols {
+		if containsString(symbols, sym) {
+			delete(w.last, sym)
+			continue
+		}
+		out = append(out, sym)
+	}
+	w.symbols = out
+}
+
+// Symbols returns the watched symbols.
+func (w *QuoteWatcher) Symbols() []string {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	return append([]string(nil), w.symbols...)
+}
+
+// Close stops the watcher, it is safe to call multiple times.
+func (w *QuoteWatcher) Close() error {
+	w.closeOnce.Do(func() { close(w.done) })
+	return nil
+}
+
+func (w *QuoteWatcher) run(ctx context.Context) {
+	defer close(w.ch)
+
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+
+	for {
+		if !w.poll(ctx) {
+			return
+		}
+
+		select {
+		case <-t.C:
+		case <-w.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// poll fetches the quotes once and delivers the changed ones, returns false if the watcher was stopped.
+func (w *QuoteWatcher) poll(ctx context.Context) bool {
+	symbols := w.Symbols()
+	if len(symbols) == 0 {
+		return true
+	}
+
+	qs, err := w.c.BatchQuotes(ctx, symbols, nil)
+	if err != nil && w.OnError != nil && ctx.Err() == nil {
+		w.OnError(err)
+	}
+
+	var evs []*QuoteEvent
+	w.mux.Lock()
+	for _, sym := range w.symbols {
+		q := qs[sym]
+		if q == nil {
+			continue
+		}
+		prev := w.last[sym]
+		if !quoteChanged(prev, q) {
+			continue
+		}
+		w.last[sym] = q
+		evs = append(evs, &QuoteEvent{Symbol: sym, Quote: q, Prev: prev})
+	}
+	w.mux.Unlock()
+
+	for _, ev := range evs {
+		select {
+		case w.ch <- ev:
+		case <-w.done:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+type rawLevelOneEquity struct {
+	Symbol          string  `json:"key"`
+	BidPrice        float64 `json:"1"`
+	AskPrice        float64 `json:"2"`
+	LastPrice       float64 `json:"3"`
+	BidSize         float64 `json:"4"`
+	AskSize         float64 `json:"5"`
+	AskID           string  `json:"6"`
+	BidID           string  `json:"7"`
+	TotalVolume     float64 `json:"8"`
+	LastSize        float64 `json:"9"`
+	HighPrice       float64 `json:"12"`
+	LowPrice        float64 `json:"13"`
+	ClosePrice      float64 `json:"15"`
+	Marginable      bool    `json:"17"`
+	Shortable       bool    `json:"18"`
+	Volatility      float64 `json:"24"`
+	Description     string  `json:"25"`
+	LastID          string  `json:"26"`
+	Digits          int64   `json:"27"`
+	OpenPrice       float64 `json:"28"`
+	NetChange       float64 `json:"29"`
+	FiftyTwoWkHigh  float64 `json:"30"`
+	FiftyTwoWkLow   float64 `json:"31"`
+	PeRatio         float64 `json:"32"`
+	DivAmount       float64 `json:"33"`
+	DivYield        float64 `json:"34"`
+	ExchangeName    string  `json:"39"`
+	DivDate         string  `json:"40"`
+	SecurityStatus  string  `json:"48"`
+	Mark            float64 `json:"49"`
+	QuoteTimeInLong int64   `json:"50"`
+	TradeTimeInLong int64   `json:"51"`
+}
+
+// ParseLevelOneEquity decodes a full QUOTE record (see Streamer.Snapshot) into an *EquityQuote.
+func ParseLevelOneEquity(v Any) (*EquityQuote, error) {
+	var r rawLevelOneEquity
+	if err := json.Unmarshal(marshalAny(v), &r); err != nil {
+		return nil, err
+	}
+
+	q := &EquityQuote{
+		BaseQuote: BaseQuote{
+			AssetType:       AssetTypeEquity,
+			Symbol:          r.Symbol,
+			Description:     r.Description,
+			ExchangeName:    r.ExchangeName,
+			SecurityStatus:  r.SecurityStatus,
+			Digits:          r.Digits,
+			BidPrice:        r.BidPrice,
+			BidSize:         int64(r.BidSize),
+			BidID:           r.BidID,
+			AskPrice:        r.AskPrice,
+			AskSize:         int64(r.AskSize),
+			AskID:           r.AskID,
+			LastPrice:       r.LastPrice,
+			LastSize:        int64(r.LastSize),
+			LastID:          r.LastID,
+			Mark:            r.Mark,
+			OpenPrice:       r.OpenPrice,
+			HighPrice:       r.HighPrice,
+			LowPrice:        r.LowPrice,
+			ClosePrice:      r.ClosePrice,
+			NetChange:       r.NetChange,
+			TotalVolume:     int64(r.TotalVolume),
+			QuoteTimeInLong: r.QuoteTimeInLong,
+			TradeTimeInLong: r.TradeTimeInLong,
+		},
+		FiftyTwoWkHigh: r.FiftyTwoWkHigh,
+		FiftyTwoWkLow:  r.FiftyTwoWkLow,
+		PeRatio:        r.PeRatio,
+		DivAmount:      r.DivAmount,
+		DivYield:       r.DivYield,
+		DivDate:        r.DivDate,
+		Marginable:     r.Marginable,
+		Shortable:      r.Shortable,
+		Volatility:     r.Volatility,
+	}
+	if r.ClosePrice != 0 {
+		q.PercentChange = r.NetChange / r.ClosePrice * 100
+	}
+	return q, nil
+}
+
+// QuoteEventSubscription delivers streaming equity quotes as QuoteEvents.
+type QuoteEventSubscription struct {
+	*Subscription
+
+	// C shadows Subscription.C.
+	C <-chan *QuoteEvent
+}
+
+// QuoteEvents subscribes to the level one quotes of symbols and delivers the full quote on every change,
+// the events have the same shape as the ones from QuoteWatcher. Every update is delivered with the state
+// it produced, even if the consumer falls behind.
+func (s *Streamer) QuoteEvents(ctx context.Context, symbols ...string) (*QuoteEventSubscription, error) {
+	const svc = "QUOTE"
+	const allFields = "0,1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19,20,21,22,23,24,25,26,27,28,29,30,31," +
+		"32,33,34,35,36,37,38,39,40,41,42,43,44,45,46,47,48,49,50,51"
+
+	// the full records are merged by dispatch, so each one is the state right after its update
+	sub, err := s.SubscribeWithOptions(ctx, svc, StreamRequestParams{
+		Keys:   strings.Join(symbols, ","),
+		Fields: allFields,
+	}, &SubscribeOptions{FullRecords: true})
+	if err != nil {
+		return nil, err
+	}
+
+	last := map[string]Quote{}
+	ch := make(chan *QuoteEvent, cap(sub.ch))
+	sub.forward(func(v Any) {
+		q, err := ParseLevelOneEquity(v)
+		if err != nil || q.Symbol == "" {
+			return
+		}
+		prev := last[q.Symbol]
+		if !quoteChanged(prev, q) {
+			return
+		}
+		last[q.Symbol] = q
+		select {
+		case ch <- &QuoteEvent{Symbol: q.Symbol, Quote: q, Prev: prev}:
+		case <-sub.cancel:
+		}
+	}, func() { close(ch) })
+
+	return &QuoteEventSubscription{Subscription: sub, C: ch}, nil
+}
@@ -0,0 +1,468 @@
+package td
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Quote is implemented by all the quote types, use a type switch to access the fields specific to an asset type.
+// Ticker and MarkPrice are the Symbol and Mark fields, a method can't have the same name as a field.
+type Quote interface {
+	Base() *BaseQuote
+	Ticker() string
+	Bid() float64
+	Ask() float64
+	Last() float64
+	MarkPrice() float64
+	Time() time.Time
+}
+
+// BaseQuote has the fields shared by all asset types.
+type BaseQuote struct {
+	AssetType      AssetType `json:"assetType,omitempty"`
+	AssetMainType  string    `json:"assetMainType,omitempty"`
+	Symbol         string    `json:"symbol,omitempty"`
+	Description    string    `json:"description,omitempty"`
+	Exchange       string    `json:"exchange,omitempty"`
+	ExchangeName   string    `json:"exchangeName,omitempty"`
+	SecurityStatus string    `json:"securityStatus,omitempty"`
+	Delayed        bool      `json:"delayed,omitempty"`
+	Digits         int64     `json:"digits,omitempty"`
+
+	BidPrice  float64 `json:"bidPrice,omitempty"`
+	BidSize   int64   `json:"bidSize,omitempty"`
+	BidID     string  `json:"bidId,omitempty"`
+	AskPrice  float64 `json:"askPrice,omitempty"`
+	AskSize   int64   `json:"askSize,omitempty"`
+	AskID     string  `json:"askId,omitempty"`
+	LastPrice float64 `json:"lastPrice,omitempty"`
+	LastSize  int64   `json:"lastSize,omitempty"`
+	LastID    string  `json:"lastId,omitempty"`
+	Mark      float64 `json:"mark,omitempty"`
+
+	OpenPrice     float64 `json:"openPrice,omitempty"`
+	HighPrice     float64 `json:"highPrice,omitempty"`
+	LowPrice      float64 `json:"lowPrice,omitempty"`
+	ClosePrice    float64 `json:"closePrice,omitempty"`
+	NetChange     float64 `json:"netChange,omitempty"`
+	PercentChange float64 `json:"percentChange,omitempty"`
+	TotalVolume   int64   `json:"totalVolume,omitempty"`
+
+	QuoteTimeInLong int64 `json:"quoteTimeInLong,omitempty"`
+	TradeTimeInLong int64 `json:"tradeTimeInLong,omitempty"`
+}
+
+func (q *BaseQuote) Base() *BaseQuote { return q }
+
+func (q *BaseQuote) Ticker() string     { return q.Symbol }
+func (q *BaseQuote) Bid() float64       { return q.BidPrice }
+func (q *BaseQuote) Ask() float64       { return q.AskPrice }
+func (q *BaseQuote) Last() float64      { return q.LastPrice }
+func (q *BaseQuote) MarkPrice() float64 { return q.Mark }
+
+// Time returns the time of the last update, the later of QuoteTime and TradeTime, or the zero time if neither is set.
+func (q *BaseQuote) Time() time.Time {
+	ms := q.QuoteTimeInLong
+	if q.TradeTimeInLong > ms {
+		ms = q.TradeTimeInLong
+	}
+	return msTime(ms)
+}
+
+// QuoteTime returns the time of the last bid/ask update, or the zero time if it isn't set.
+func (q *BaseQuote) QuoteTime() time.Time { return msTime(q.QuoteTimeInLong) }
+
+// TradeTime returns the time of the last trade, or the zero time if it isn't set.
+func (q *BaseQuote) TradeTime() time.Time { return msTime(q.TradeTimeInLong) }
+
+// msTime converts TD's epoch milliseconds to a time, 0 is the zero time rather than the epoch.
+func msTime(ms int64) time.Time {
+	if ms <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+// Spread returns the difference between the ask and the bid.
+func (q *BaseQuote) Spread() float64 {
+	return q.AskPrice - q.BidPrice
+}
+
+// EquityQuote is the quote of a stock or an ETF.
+type EquityQuote struct {
+	BaseQuote
+
+	FiftyTwoWkHigh               float64 `json:"52WkHigh,omitempty"`
+	FiftyTwoWkLow                float64 `json:"52WkLow,omitempty"`
+	PeRatio                      float64 `json:"peRatio,omitempty"`
+	DivAmount                    float64 `json:"divAmount,omitempty"`
+	DivYield                     float64 `json:"divYield,omitempty"`
+	DivDate                      string  `json:"divDate,omitempty"`
+	Marginable                   bool    `json:"marginable,omitempty"`
+	Shortable                    bool    `json:"shortable,omitempty"`
+	Volatility                   float64 `json:"volatility,omitempty"`
+	NAV                          float64 `json:"nAV,omitempty"`
+	RegularMarketLastPrice       float64 `json:"regularMarketLastPrice,omitempty"`
+	RegularMarketLastSize        int64   `json:"regularMarketLastSize,omitempty"`
+	RegularMarketNetChange       float64 `json:"regularMarketNetChange,omitempty"`
+	RegularMarketPercentChange   float64 `json:"regularMarketPercentChangeInDouble,omitempty"`
+	RegularMarketTradeTimeInLong int64   `json:"regularMarketTradeTimeInLong,omitempty"`
+}
+
+// OptionQuote is the quote of an equity or index option.
+type OptionQuote struct {
+	BaseQuote
+
+	ContractType           string  `json:"contractType,omitempty"`
+	Underlying             string  `json:"underlying,omitempty"`
+	UnderlyingPrice        float64 `json:"underlyingPrice,omitempty"`
+	StrikePrice            float64 `json:"strikePrice,omitempty"`
+	Multiplier             float64 `json:"multiplier,omitempty"`
+	Deliverables           string  `json:"deliverables,omitempty"`
+	ExpirationDay          int     `json:"expirationDay,omitempty"`
+	ExpirationMonth        int     `json:"expirationMonth,omitempty"`
+	ExpirationYear         int     `json:"expirationYear,omitempty"`
+	ExpirationType         string  `json:"expirationType,omitempty"`
+	UvExpirationType       string  `json:"uvExpirationType,omitempty"`
+	SettlementType         string  `json:"settlementType,omitempty"`
+	OpenInterest           float64 `json:"openInterest,omitempty"`
+	Volatility             float64 `json:"volatility,omitempty"`
+	MoneyIntrinsicValue    float64 `json:"moneyIntrinsicValue,omitempty"`
+	TimeValue              float64 `json:"timeValue,omitempty"`
+	TheoreticalOptionValue float64 `json:"theoreticalOptionValue,omitempty"`
+	Delta                  float64 `json:"delta,omitempty"`
+	Gamma                  float64 `json:"gamma,omitempty"`
+	Theta                  float64 `json:"theta,omitempty"`
+	Vega                   float64 `json:"vega,omitempty"`
+	Rho                    float64 `json:"rho,omitempty"`
+}
+
+// FutureQuote is the quote of a futures contract.
+type FutureQuote struct {
+	BaseQuote
+
+	Product               string  `json:"product,omitempty"`
+	Tick                  float64 `json:"tick,omitempty"`
+	TickAmount            float64 `json:"tickAmount,omitempty"`
+	OpenInterest          float64 `json:"openInterest,omitempty"`
+	FutureMultiplier      float64 `json:"futureMultiplier,omitempty"`
+	FuturePercentChange   float64 `json:"futurePercentChange,omitempty"`
+	FuturePriceFormat     string  `json:"futurePriceFormat,omitempty"`
+	FutureSettlementPrice float64 `json:"futureSettlementPrice,omitempty"`
+	FutureTradingHours    string  `json:"futureTradingHours,omitempty"`
+	FutureActiveSymbol    string  `json:"futureActiveSymbol,omitempty"`
+	FutureExpirationDate  int64   `json:"futureExpirationDate,omitempty"`
+	FutureIsActive        bool    `json:"futureIsActive,omitempty"`
+	FutureIsTradable      bool    `json:"futureIsTradable,omitempty"`
+}
+
+// FutureOptionQuote is the quote of an option on a futures contract, TD sends most of its prices as ...InDouble fields,
+// they are copied to the regular fields.
+type FutureOptionQuote struct {
+	BaseQuote
+
+	ContractType          string  `json:"contractType,omitempty"`
+	Underlying            string  `json:"underlying,omitempty"`
+	StrikePrice           float64 `json:"strikePriceInDouble,omitempty"`
+	Multiplier            float64 `json:"multiplierInDouble,omitempty"`
+	ExerciseType          string  `json:"exerciseType,omitempty"`
+	ExpirationDate        int64   `json:"futureExpirationDate,omitempty"`
+	Product               string  `json:"product,omitempty"`
+	Tick                  float64 `json:"tick,omitempty"`
+	TickAmount            float64 `json:"tickAmount,omitempty"`
+	OpenInterest          float64 `json:"openInterest,omitempty"`
+	Volatility            float64 `json:"volatility,omitempty"`
+	MoneyIntrinsicValue   float64 `json:"moneyIntrinsicValueInDouble,omitempty"`
+	TimeValue             float64 `json:"timeValueInDouble,omitempty"`
+	FuturePercentChange   float64 `json:"futurePercentChange,omitempty"`
+	FutureSettlementPrice float64 `json:"futureSettlementPrice,omitempty"`
+	FutureTradingHours    string  `json:"futureTradingHours,omitempty"`
+	FutureIsTradable      bool    `json:"futureIsTradable,omitempty"`
+	Delta                 float64 `json:"deltaInDouble,omitempty"`
+	Gamma                 float64 `json:"gammaInDouble,omitempty"`
+	Theta                 float64 `json:"thetaInDouble,omitempty"`
+	Vega                  float64 `json:"vegaInDouble,omitempty"`
+	Rho                   float64 `json:"rhoInDouble,omitempty"`
+}
+
+// ForexQuote is the quote of a currency pair, TD sends its prices as ...InDouble fields, they are copied to the regular fields.
+type ForexQuote struct {
+	BaseQuote
+
+	Product        string  `json:"product,omitempty"`
+	Tick           float64 `json:"tick,omitempty"`
+	TickAmount     float64 `json:"tickAmount,omitempty"`
+	TradingHours   string  `json:"tradingHours,omitempty"`
+	IsTradable     bool    `json:"isTradable,omitempty"`
+	MarketMaker    string  `json:"marketMaker,omitempty"`
+	FiftyTwoWkHigh float64 `json:"52WkHighInDouble,omitempty"`
+	FiftyTwoWkLow  float64 `json:"52WkLowInDouble,omitempty"`
+}
+
+// IndexQuote is the quote of an index like $SPX.X, indices have no bid or ask.
+type IndexQuote struct {
+	BaseQuote
+
+	FiftyTwoWkHigh float64 `json:"52WkHigh,omitempty"`
+	FiftyTwoWkLow  float64 `json:"52WkLow,omitempty"`
+}
+
+// MutualFundQuote is the quote of a mutual fund, the price is the previous day's NAV.
+type MutualFundQuote struct {
+	BaseQuote
+
+	NAV            float64 `json:"nAV,omitempty"`
+	FiftyTwoWkHigh float64 `json:"52WkHigh,omitempty"`
+	FiftyTwoWkLow  float64 `json:"52WkLow,omitempty"`
+	PeRatio        float64 `json:"peRatio,omitempty"`
+	DivAmount      float64 `json:"divAmount,omitempty"`
+	DivYield       float64 `json:"divYield,omitempty"`
+	DivDate        string  `json:"divDate,omitempty"`
+}
+
+// UnknownQuote is returned for asset types without a specific quote type, Raw is the original json.
+type UnknownQuote struct {
+	BaseQuote
+
+	Raw json.RawMessage `json:"-"`
+}
+
+// inDoubleQuote has the ...InDouble variants of the base prices, some asset types only send those.
+type inDoubleQuote struct {
+	BidPrice   float64 `json:"bidPriceInDouble"`
+	AskPrice   float64 `json:"askPriceInDouble"`
+	LastPrice  float64 `json:"lastPriceInDouble"`
+	OpenPrice  float64 `json:"openPriceInDouble"`
+	HighPrice  float64 `json:"highPriceInDouble"`
+	LowPrice   float64 `json:"lowPriceInDouble"`
+	ClosePrice float64 `json:"closePriceInDouble"`
+	NetChange  float64 `json:"changeInDouble"`
+	Mark       float64 `json:"markInDouble"`
+}
+
+// ParseQuote decodes a single quote into the type matching its assetType.
+func ParseQuote(b []byte) (Quote, error) {
+	var base BaseQuote
+	if err := json.Unmarshal(b, &base); err != nil {
+		return nil, err
+	}
+
+	var q Quote
+	switch base.AssetType {
+	case AssetTypeEquity, AssetTypeETF:
+		q = &EquityQuote{}
+	case AssetTypeOption:
+		q = &OptionQuote{}
+	case AssetTypeFuture:
+		q = &FutureQuote{}
+	case AssetTypeFutureOption:
+		q = &FutureOptionQuote{}
+	case AssetTypeForex:
+		q = &ForexQuote{}
+	case AssetTypeIndex:
+		q = &IndexQuote{}
+	case AssetTypeMutualFund:
+		q = &MutualFundQuote{}
+	default:
+		return &UnknownQuote{BaseQuote: base, Raw: append(json.RawMessage(nil), b...)}, nil
+	}
+
+	if err := json.Unmarshal(b, q); err != nil {
+		return nil, err
+	}
+
+	var d inDoubleQuote
+	if err := json.Unmarshal(b, &d); err != nil {
+		return nil, err
+	}
+	qb := q.Base()
+	fillZero(&qb.BidPrice, d.BidPrice)
+	fillZero(&qb.AskPrice, d.AskPrice)
+	fillZero(&qb.LastPrice, d.LastPrice)
+	fillZero(&qb.OpenPrice, d.OpenPrice)
+	fillZero(&qb.HighPrice, d.HighPrice)
+	fillZero(&qb.LowPrice, d.LowPrice)
+	fillZero(&qb.ClosePrice, d.ClosePrice)
+	fillZero(&qb.NetChange, d.NetChange)
+	fillZero(&qb.Mark, d.Mark)
+
+	return q, nil
+}
+
+func fillZero(dst *float64, v float64) {
+	if *dst == 0 {
+		*dst = v
+	}
+}
+
+// quoteMap decodes the response of the quotes endpoints.
+type quoteMap map[string]Quote
+
+func (m *quoteMap) UnmarshalJSON(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	out := make(quoteMap, len(raw))
+	for sym, qb := range raw {
+		q, err := ParseQuote(qb)
+		if err != nil {
+			return err
+		}
+		out[sym] = q
+	}
+	*m = out
+	return nil
+}
+
+const (
+	defaultQuotesChunkSize   = 100
+	defaultQuotesConcurrency = 4
+
+	// maxQuotesQueryLen keeps the escaped symbol list well under the URL length limits.
+	maxQuotesQueryLen = 2000
+)
+
+type BatchQuotesParams struct {
+	// ChunkSize is the max number of symbols per request, default is 100.
+	ChunkSize int
+
+	// Concurrency is the max number of requests in flight, default is 4.
+	// Every request still goes through the client's rate limiter.
+	Concurrency int
+}
+
+// QuotesChunkError is the error of a single request of a batch.
+type QuotesChunkError struct {
+	Symbols []string
+	Err     error
+}
+
+func (e *QuotesChunkError) Error() string {
+	return fmt.Sprintf("quotes %s: %v", strings.Join(e.Symbols, ","), e.Err)
+}
+
+func (e *QuotesChunkError) Unwrap() error { return e.Err }
+
+// BatchQuotesError is returned by BatchQuotes when some of the requests failed,
+// the quotes of the successful requests are still returned.
+type BatchQuotesError struct {
+	Chunks []*QuotesChunkError
+}
+
+func (e *BatchQuotesError) Error() string {
+	return fmt.Sprintf("%d of the quote requests failed, first error: %v", len(e.Chunks), e.Chunks[0])
+}
+
+// Symbols returns the symbols of all the failed requests.
+func (e *BatchQuotesError) Symbols() (out []string) {
+	for _, c := range e.Chunks {
+		out = append(out, c.Symbols...)
+	}
+	return
+}
+
+// BatchQuotes is like Quotes but splits the symbols into multiple concurrent requests,
+// duplicate symbols are ignored. If some requests fail, the returned error is a *BatchQuotesError
+// and out has the quotes of the other requests. params can be nil.
+func (c *Client) BatchQuotes(ctx context.Context, symbols []string, params *BatchQuotesParams) (out map[string]Quote, err error) {
+	var p BatchQuotesParams
+	if params != nil {
+		p = *params
+	}
+	if p.ChunkSize <= 0 {
+		p.ChunkSize = defaultQuotesChunkSize
+	}
+	if p.Concurrency <= 0 {
+		p.Concurrency = defaultQuotesConcurrency
+	}
+
+	chunks := chunkSymbols(symbols, p.ChunkSize, maxQuotesQueryLen)
+	if len(chunks) == 0 {
+		return nil, ErrNoSymbols
+	}
+
+	var (
+		mux  sync.Mutex
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, p.Concurrency)
+		errs = make([]*QuotesChunkError, len(chunks))
+	)
+
+	out = make(map[string]Quote, len(symbols))
+	for i, chunk := range chunks {
+		i, chunk := i, chunk
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			qs, err := c.Quotes(ctx, chunk...)
+
+			mux.Lock()
+			defer mux.Unlock()
+			if err != nil {
+				errs[i] = &QuotesChunkError{Symbols: chunk, Err: err}
+				return
+			}
+			for sym, q := range qs {
+				out[sym] = q
+			}
+		}()
+	}
+	wg.Wait()
+
+	var berr BatchQuotesError
+	for _, e := range errs {
+		if e != nil {
+			berr.Chunks = append(berr.Chunks, e)
+		}
+	}
+	if len(berr.Chunks) > 0 {
+		err = &berr
+	}
+	return
+}
+
+// quoteSymbols escapes and joins symbols for the symbol query parameter.
+func quoteSymbols(symbols []string) string {
+	escaped := make([]string, len(symbols))
+	for i, sym := range symbols {
+		escaped[i] = url.QueryEscape(sym)
+	}
+	return strings.Join(escaped, ",")
+}
+
+// chunkSymbols dedups symbols and splits them into chunks of at most size symbols and maxLen escaped bytes.
+func chunkSymbols(symbols []string, size, maxLen int) (out [][]string) {
+	var (
+		seen  = make(map[string]bool, len(symbols))
+		chunk []string
+		n     int
+	)
+	for _, sym := range symbols {
+		if sym = strings.TrimSpace(sym); sym == "" || seen[sym] {
+			continue
+		}
+		seen[sym] = true
+
+		l := len(url.QueryEscape(sym)) + 1
+		if len(chunk) > 0 && (len(chunk) == size || n+l > maxLen) {
+			out, chunk, n = append(out, chunk), nil, 0
+		}
+		chunk, n = append(chunk, sym), n+l
+	}
+	if len(chunk) > 0 {
+		out = append(out, chunk)
+	}
+	return
+}
@@ -0,0 +1,154 @@
+package td_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go.oneofone.dev/td"
+	"go.oneofone.dev/td/tdtest"
+)
+
+func TestBatchQuotes(t *testing.T) {
+	srv := tdtest.NewServer()
+	defer srv.Close()
+
+	symbols := []string{"AAPL", "MSFT", "/ES", "$SPX.X", "BAD", "GOOG", "AAPL"}
+	for _, sym := range symbols {
+		srv.SetQuote(sym, map[string]interface{}{"assetType": "EQUITY", "symbol": sym, "lastPrice": 1})
+	}
+	srv.OnQuotes = func(symbols []string) int {
+		for _, sym := range symbols {
+			if sym == "BAD" {
+				return http.StatusBadRequest
+			}
+		}
+		return http.StatusOK
+	}
+
+	c, err := srv.Client(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	qs, err := c.BatchQuotes(context.Background(), symbols, &td.BatchQuotesParams{ChunkSize: 2, Concurrency: 2})
+
+	var berr *td.BatchQuotesError
+	if !errors.As(err, &berr) {
+		t.Fatalf("expected a *BatchQuotesError, got %v", err)
+	}
+	if failed := strings.Join(berr.Symbols(), ","); failed != "BAD,GOOG" {
+		t.Fatalf("unexpected failed symbols: %s", failed)
+	}
+
+	for _, sym := range []string{"AAPL", "MSFT", "/ES", "$SPX.X"} {
+		if q := qs[sym]; q == nil || q.Base().Symbol != sym {
+			t.Fatalf("missing quote for %s: %v", sym, qs)
+		}
+	}
+
+	if n := len(srv.QuoteRequests()); n != 3 {
+		t.Fatalf("expected 3 requests, got %d", n)
+	}
+}
+
+func TestClientQuote(t *testing.T) {
+	srv := tdtest.NewServer()
+	defer srv.Close()
+
+	srv.SetQuote("AAPL", map[string]interface{}{"assetType": "EQUITY", "symbol": "AAPL", "lastPrice": 100})
+	srv.SetQuote("/ES", map[string]interface{}{"assetType": "FUTURE", "symbol": "/ES", "lastPrice": 3370.5})
+	srv.OnQuotes = func(symbols []string) int {
+		if symbols[0] == "BAD" {
+			return http.StatusNotFound
+		}
+		return http.StatusOK
+	}
+
+	c, err := srv.Client(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	q, err := c.Quote(ctx, "AAPL")
+	if _, ok := q.(*td.EquityQuote); err != nil || !ok || q.Last() != 100 {
+		t.Fatalf("unexpected quote: %T %+v %v", q, q, err)
+	}
+
+	// the symbol is escaped in the path
+	q, err = c.Quote(ctx, "/ES")
+	if _, ok := q.(*td.FutureQuote); err != nil || !ok || q.Ticker() != "/ES" || q.Last() != 3370.5 {
+		t.Fatalf("unexpected quote: %T %+v %v", q, q, err)
+	}
+
+	if reqs := srv.QuoteRequests(); len(reqs) != 2 || len(reqs[1]) != 1 || reqs[1][0] != "/ES" {
+		t.Fatalf("unexpected requests: %q", reqs)
+	}
+
+	if _, err = c.Quote(ctx, "MSFT"); err == nil {
+		t.Fatal("expected an error for a missing quote")
+	}
+	if _, err = c.Quote(ctx, "BAD"); err == nil {
+		t.Fatal("expected an error for a failed request")
+	}
+}
+
+func TestQuoteWatcher(t *testing.T) {
+	srv := tdtest.NewServer()
+	defer srv.Close()
+
+	srv.SetQuote("AAPL", map[string]interface{}{"assetType": "EQUITY", "symbol": "AAPL", "lastPrice": 100, "quoteTimeInLong": 1})
+	srv.SetQuote("MSFT", map[string]interface{}{"assetType": "EQUITY", "symbol": "MSFT", "lastPrice": 200, "quoteTimeInLong": 1})
+
+	c, err := srv.Client(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := c.NewQuoteWatcher(10*time.Millisecond, "AAPL", "MSFT")
+	w.OnError = func(err error) { t.Error(err) }
+	w.Start(ctx)
+
+	seen := map[string]bool{}
+	for len(seen) < 2 {
+		ev := recvQuoteEvent(t, w.C)
+		if ev.Prev != nil {
+			t.Fatalf("unexpected previous quote on the first event: %+v", ev)
+		}
+		seen[ev.Symbol] = true
+	}
+
+	// let a few polls go by without changes
+	time.Sleep(50 * time.Millisecond)
+	srv.SetQuote("AAPL", map[string]interface{}{"assetType": "EQUITY", "symbol": "AAPL", "lastPrice": 101, "quoteTimeInLong": 2})
+
+	ev := recvQuoteEvent(t, w.C)
+	if ev.Symbol != "AAPL" || ev.Quote.Base().LastPrice != 101 || ev.Prev == nil || ev.Prev.Base().LastPrice != 100 {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+
+	w.Close()
+	for range w.C {
+	}
+}
+
+func recvQuoteEvent(t *testing.T, ch <-chan *td.QuoteEvent) *td.QuoteEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-ch:
+		if !ok {
+			t.Fatal("channel closed")
+		}
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a quote event")
+		return nil
+	}
+}
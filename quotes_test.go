@@ -0,0 +1,186 @@
+package td
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseQuote(t *testing.T) {
+	tests := []struct {
+		name  string
+		json  string
+		check func(t *testing.T, q Quote)
+	}{
+		{"equity", `{"assetType":"EQUITY","symbol":"AAPL","bidPrice":100.1,"askPrice":100.2,"lastPrice":100.15,"mark":100.15,"peRatio":30.5,"quoteTimeInLong":1597411800000}`, func(t *testing.T, q Quote) {
+			eq, ok := q.(*EquityQuote)
+			if !ok {
+				t.Fatalf("expected *EquityQuote, got %T", q)
+			}
+			if eq.PeRatio != 30.5 || eq.Symbol != "AAPL" || eq.QuoteTime().Unix() != 1597411800 {
+				t.Fatalf("unexpected quote: %+v", eq)
+			}
+		}},
+		{"etf", `{"assetType":"ETF","symbol":"SPY"}`, func(t *testing.T, q Quote) {
+			if _, ok := q.(*EquityQuote); !ok {
+				t.Fatalf("expected *EquityQuote, got %T", q)
+			}
+		}},
+		{"option", `{"assetType":"OPTION","symbol":"AMD_081420C80","strikePrice":80,"delta":0.55,"contractType":"C"}`, func(t *testing.T, q Quote) {
+			oq, ok := q.(*OptionQuote)
+			if !ok {
+				t.Fatalf("expected *OptionQuote, got %T", q)
+			}
+			if oq.StrikePrice != 80 || oq.Delta != 0.55 {
+				t.Fatalf("unexpected quote: %+v", oq)
+			}
+		}},
+		{"forex", `{"assetType":"FOREX","symbol":"EUR/USD","bidPriceInDouble":1.18,"askPriceInDouble":1.1802,"lastPriceInDouble":1.1801}`, func(t *testing.T, q Quote) {
+			b := q.Base()
+			if _, ok := q.(*ForexQuote); !ok || b.BidPrice != 1.18 || b.AskPrice != 1.1802 || b.LastPrice != 1.1801 {
+				t.Fatalf("unexpected quote: %T %+v", q, b)
+			}
+		}},
+		{"future option", `{"assetType":"FUTURE_OPTION","symbol":"./ESU20C3400","strikePriceInDouble":3400,"deltaInDouble":0.4,"markInDouble":12.5}`, func(t *testing.T, q Quote) {
+			fq, ok := q.(*FutureOptionQuote)
+			if !ok || fq.StrikePrice != 3400 || fq.Delta != 0.4 || fq.Mark != 12.5 {
+				t.Fatalf("unexpected quote: %T %+v", q, q)
+			}
+		}},
+		{"unknown", `{"assetType":"BOND","symbol":"X"}`, func(t *testing.T, q Quote) {
+			uq, ok := q.(*UnknownQuote)
+			if !ok || uq.Symbol != "X" || len(uq.Raw) == 0 {
+				t.Fatalf("unexpected quote: %T %+v", q, q)
+			}
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := ParseQuote([]byte(tc.json))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.check(t, q)
+		})
+	}
+}
+
+func TestQuoteInterface(t *testing.T) {
+	tests := []struct {
+		json                 string
+		bid, ask, last, mark float64
+		quoteTime, tradeTime int64
+	}{
+		{`{"assetType":"EQUITY","symbol":"AAPL","bidPrice":100.1,"askPrice":100.2,"lastPrice":100.15,"mark":100.15,"quoteTimeInLong":1597411800000,"tradeTimeInLong":1597411799000}`,
+			100.1, 100.2, 100.15, 100.15, 1597411800000, 1597411799000},
+		{`{"assetType":"OPTION","symbol":"AMD_081420C80","bidPrice":2.1,"askPrice":2.2,"lastPrice":2.15,"mark":2.15,"quoteTimeInLong":1597411800000,"tradeTimeInLong":1597411801000}`,
+			2.1, 2.2, 2.15, 2.15, 1597411800000, 1597411801000},
+		{`{"assetType":"FUTURE","symbol":"/ES","bidPrice":3370.25,"askPrice":3370.5,"lastPrice":3370.5,"mark":3370.5,"quoteTimeInLong":1597411800000}`,
+			3370.25, 3370.5, 3370.5, 3370.5, 1597411800000, 0},
+		{`{"assetType":"FUTURE_OPTION","symbol":"./ESU20C3400","bidPriceInDouble":12,"askPriceInDouble":13,"lastPriceInDouble":12.75,"markInDouble":12.5,"tradeTimeInLong":1597411800000}`,
+			12, 13, 12.75, 12.5, 0, 1597411800000},
+		{`{"assetType":"FOREX","symbol":"EUR/USD","bidPriceInDouble":1.18,"askPriceInDouble":1.1802,"lastPriceInDouble":1.1801,"markInDouble":1.1801,"quoteTimeInLong":1597411800000}`,
+			1.18, 1.1802, 1.1801, 1.1801, 1597411800000, 0},
+		{`{"assetType":"INDEX","symbol":"$SPX.X","lastPrice":3372.85,"tradeTimeInLong":1597411800000}`,
+			0, 0, 3372.85, 0, 0, 1597411800000},
+		{`{"assetType":"MUTUAL_FUND","symbol":"VFIAX","closePrice":310.5,"lastPrice":310.5}`,
+			0, 0, 310.5, 0, 0, 0},
+		{`{"assetType":"BOND","symbol":"X","bidPrice":99,"askPrice":101}`,
+			99, 101, 0, 0, 0, 0},
+	}
+
+	for _, tc := range tests {
+		q, err := ParseQuote([]byte(tc.json))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := q.Base()
+		if q.Ticker() != b.Symbol || q.Ticker() == "" || q.Bid() != tc.bid || q.Ask() != tc.ask || q.Last() != tc.last || q.MarkPrice() != tc.mark {
+			t.Fatalf("%T: unexpected %v %v %v %v %v", q, q.Ticker(), q.Bid(), q.Ask(), q.Last(), q.MarkPrice())
+		}
+
+		ms := tc.quoteTime
+		if tc.tradeTime > ms {
+			ms = tc.tradeTime
+		}
+		checkMsTime(t, q, "Time", q.Time(), ms)
+		checkMsTime(t, q, "QuoteTime", b.QuoteTime(), tc.quoteTime)
+		checkMsTime(t, q, "TradeTime", b.TradeTime(), tc.tradeTime)
+	}
+}
+
+// checkMsTime checks that tm is ms since the epoch, or the zero time if ms is 0.
+func checkMsTime(t *testing.T, q Quote, name string, tm time.Time, ms int64) {
+	t.Helper()
+	if ms == 0 {
+		if !tm.IsZero() {
+			t.Fatalf("%T: expected a zero %s, got %v", q, name, tm)
+		}
+	} else if tm.UnixNano() != ms*int64(time.Millisecond) {
+		t.Fatalf("%T: expected %s %v, got %v", q, name, ms, tm)
+	}
+}
+
+func TestChunkSymbols(t *testing.T) {
+	chunks := chunkSymbols([]string{"A", "B", " A", "", "/ES", "$SPX.X", "C"}, 2, 100)
+	if len(chunks) != 3 || len(chunks[0]) != 2 || chunks[1][0] != "/ES" || chunks[2][0] != "C" {
+		t.Fatalf("unexpected chunks: %q", chunks)
+	}
+
+	// "%2FES," is 6 bytes, so only 2 fit in 12
+	chunks = chunkSymbols([]string{"/ES", "/NQ", "/CL"}, 100, 12)
+	if len(chunks) != 2 || len(chunks[0]) != 2 {
+		t.Fatalf("unexpected chunks: %q", chunks)
+	}
+
+	if q := quoteSymbols([]string{"/ES", "$SPX.X", "BRK.B"}); q != "%2FES,%24SPX.X,BRK.B" {
+		t.Fatalf("unexpected query: %s", q)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(3, 3*time.Second)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if d := l.reserve(now); d > 0 {
+			t.Fatalf("request %d shouldn't wait, got %v", i, d)
+		}
+	}
+	if d := l.reserve(now); d != time.Second {
+		t.Fatalf("expected to wait 1s, got %v", d)
+	}
+	if d := l.reserve(now.Add(10 * time.Second)); d > 0 {
+		t.Fatalf("the bucket should be refilled, got %v", d)
+	}
+}
+
+func TestClientRateLimit(t *testing.T) {
+	var c Client
+	ctx := context.Background()
+
+	// requests aren't limited by default
+	for i := 0; i < 2*DefaultRateLimit; i++ {
+		if err := c.wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.limiter != nil {
+		t.Fatal("unexpected default limiter")
+	}
+
+	c.SetRateLimit(1, time.Hour)
+	if err := c.wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := c.wait(tctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected the second request to wait, got %v", err)
+	}
+
+	c.SetRateLimit(0, time.Hour)
+	if err := c.wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
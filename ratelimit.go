@@ -0,0 +1,67 @@
+package td
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// DefaultRateLimit is the number of requests per minute TD allows per application,
+	// pass it to Client.SetRateLimit to stay under it.
+	DefaultRateLimit = 120
+)
+
+// rateLimiter spaces requests so at most burst requests are sent per burst*interval.
+type rateLimiter struct {
+	interval time.Duration
+	burst    int
+	tat      time.Time // theoretical arrival time of the next request
+}
+
+func newRateLimiter(n int, per time.Duration) *rateLimiter {
+	if n <= 0 || per <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: per / time.Duration(n), burst: n}
+}
+
+// reserve returns how long to wait before sending a request, c.lmux must be held.
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	tat := l.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	l.tat = tat.Add(l.interval)
+	return tat.Add(-time.Duration(l.burst-1) * l.interval).Sub(now)
+}
+
+// SetRateLimit limits the client to n requests per the given duration, n <= 0 disables the limit.
+// Requests aren't limited by default.
+func (c *Client) SetRateLimit(n int, per time.Duration) {
+	c.lmux.Lock()
+	c.limiter = newRateLimiter(n, per)
+	c.lmux.Unlock()
+}
+
+// wait blocks until the rate limiter set by SetRateLimit allows another request or ctx is done.
+func (c *Client) wait(ctx context.Context) error {
+	c.lmux.Lock()
+	var d time.Duration
+	if c.limiter != nil {
+		d = c.limiter.reserve(time.Now())
+	}
+	c.lmux.Unlock()
+
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,267 @@
+package td
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// recordMagic is the header of a recording, the rest is a list of frames, each frame is
+// uvarint(nanoseconds since the previous frame) + uvarint(length) + the raw frame.
+const recordMagic = "TDSR\x01"
+
+var (
+	ErrInvalidRecording = errors.New("invalid recording header")
+	ErrReplayClosed     = errors.New("replay closed")
+)
+
+// Recorder writes raw streamer frames with their timestamps, use Streamer.SetRecorder to record a session
+// and NewReplay to play it back.
+type Recorder struct {
+	mux  sync.Mutex
+	w    *bufio.Writer
+	c    io.Closer
+	last int64
+	buf  [binary.MaxVarintLen64]byte
+	err  error
+}
+
+// NewRecorder writes the recording header to w and returns a recorder,
+// if w is an io.Closer, it will be closed by Recorder.Close.
+func NewRecorder(w io.Writer) (*Recorder, error) {
+	r := &Recorder{w: bufio.NewWriter(w)}
+	if c, ok := w.(io.Closer); ok {
+		r.c = c
+	}
+	if _, err := r.w.WriteString(recordMagic); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Record writes a frame received at t, it returns the first error encountered by the recorder.
+func (r *Recorder) Record(t time.Time, frame []byte) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+
+	ts := t.UnixNano()
+	delta := ts - r.last
+	if r.last == 0 || delta < 0 {
+		delta = 0
+	}
+	r.last = ts
+
+	r.write(uint64(delta))
+	r.write(uint64(len(frame)))
+	if r.err == nil {
+		_, r.err = r.w.Write(frame)
+	}
+	return r.err
+}
+
+func (r *Recorder) write(v uint64) {
+	if r.err != nil {
+		return
+	}
+	n := binary.PutUvarint(r.buf[:], v)
+	_, r.err = r.w.Write(r.buf[:n])
+}
+
+// Flush writes any buffered frames to the underlying writer.
+func (r *Recorder) Flush() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.err = r.w.Flush()
+	return r.err
+}
+
+func (r *Recorder) Close() error {
+	err := r.Flush()
+	if r.c != nil {
+		if cerr := r.c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Replay plays back a recording through a Streamer, subscriptions and typed helpers work as usual
+// but requests are answered locally and nothing is sent anywhere.
+type Replay struct {
+	*Streamer
+	conn *replayConn
+}
+
+// NewReplay returns a replay of the recording in r, speed is a multiplier of the original pace,
+// 1 is real time, 10 is ten times faster and 0 plays it as fast as possible.
+// Subscribe first, then call Start, the streamer is closed once the recording ends.
+func NewReplay(r io.Reader, speed float64) (*Replay, error) {
+	rd := bufio.NewReader(r)
+	hdr := make([]byte, len(recordMagic))
+	if _, err := io.ReadFull(rd, hdr); err != nil || string(hdr) != recordMagic {
+		return nil, ErrInvalidRecording
+	}
+
+	conn := &replayConn{
+		rd:      rd,
+		speed:   speed,
+		start:   make(chan struct{}),
+		closed:  make(chan struct{}),
+		pending: make(chan []byte, 64),
+	}
+
+	s := newStreamer(nil, conn)
+	go s.run()
+
+	return &Replay{Streamer: s, conn: conn}, nil
+}
+
+// Start starts playing back the recorded frames.
+func (rp *Replay) Start() {
+	rp.conn.startOnce.Do(func() { close(rp.conn.start) })
+}
+
+type replayConn struct {
+	rd    *bufio.Reader
+	speed float64
+
+	start     chan struct{}
+	startOnce sync.Once
+	started   bool
+
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	// answers to the requests sent to the replay
+	pending chan []byte
+
+	next      []byte
+	nextAt    time.Time
+	ts        int64
+	startedAt time.Time
+}
+
+// ReadMessage returns the pending responses first, then the recorded frames at their recorded pace.
+func (c *replayConn) ReadMessage() (int, []byte, error) {
+	for {
+		if c.started && c.next == nil {
+			if err := c.readFrame(); err != nil {
+				return 0, nil, err
+			}
+		}
+
+		var timer *time.Timer
+		if c.next != nil {
+			d := time.Until(c.nextAt)
+			if d <= 0 {
+				b := c.next
+				c.next = nil
+				return websocket.TextMessage, b, nil
+			}
+			timer = time.NewTimer(d)
+		}
+
+		if b, err := c.wait(timer); b != nil || err != nil {
+			return websocket.TextMessage, b, err
+		}
+	}
+}
+
+// wait waits for a pending response, the start of the replay or for the timer to fire.
+func (c *replayConn) wait(timer *time.Timer) ([]byte, error) {
+	var tc <-chan time.Time
+	if timer != nil {
+		defer timer.Stop()
+		tc = timer.C
+	}
+
+	start := c.start
+	if c.started {
+		start = nil
+	}
+
+	select {
+	case b := <-c.pending:
+		return b, nil
+	case <-start:
+		c.started, c.startedAt = true, time.Now()
+	case <-tc:
+	case <-c.closed:
+		return nil, ErrReplayClosed
+	}
+	return nil, nil
+}
+
+func (c *replayConn) readFrame() error {
+	delta, err := binary.ReadUvarint(c.rd)
+	if err != nil {
+		return err
+	}
+
+	n, err := binary.ReadUvarint(c.rd)
+	if err != nil {
+		return err
+	}
+
+	b := make([]byte, n)
+	if _, err = io.ReadFull(c.rd, b); err != nil {
+		return err
+	}
+
+	// the first frame always has a delta of 0, so ts is the offset from the start of the recording
+	c.ts += int64(delta)
+
+	c.next, c.nextAt = b, time.Now()
+	if c.speed > 0 {
+		c.nextAt = c.startedAt.Add(time.Duration(float64(c.ts) / c.speed))
+	}
+	return nil
+}
+
+// WriteJSON answers every request with a successful response, except for GET requests since there is no data to return.
+func (c *replayConn) WriteJSON(v interface{}) error {
+	reqs, ok := v.(*streamRequests)
+	if !ok {
+		return nil
+	}
+
+	for _, req := range reqs.Requests {
+		var resp streamDataResponse
+		resp.Service, resp.RequestID, resp.Command = req.Service, req.RequestID, req.Command
+		resp.Timestamp = time.Now().UnixNano() / int64(time.Millisecond)
+		if req.Command == "GET" {
+			resp.Content.Code, resp.Content.Msg = 1, "not available in a replay"
+		}
+
+		b, err := json.Marshal(streamResponse{Response: []*streamDataResponse{&resp}})
+		if err != nil {
+			return err
+		}
+
+		select {
+		case c.pending <- b:
+		case <-c.closed:
+			return ErrReplayClosed
+		}
+	}
+	return nil
+}
+
+func (c *replayConn) SetReadDeadline(time.Time) error { return nil }
+
+func (c *replayConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
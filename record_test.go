@@ -0,0 +1,172 @@
+package td_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.oneofone.dev/td"
+	"go.oneofone.dev/td/tdtest"
+)
+
+// recording returns a recording of one data frame per content item, 1ms apart.
+func recording(t *testing.T, svc string, content ...map[string]interface{}) *bytes.Buffer {
+	var buf bytes.Buffer
+	rec, err := td.NewRecorder(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for i, c := range content {
+		frame, err := json.Marshal(map[string]interface{}{
+			"data": []interface{}{map[string]interface{}{
+				"service":   svc,
+				"timestamp": 1597411800000 + i,
+				"command":   "SUBS",
+				"content":   []interface{}{c},
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = rec.Record(start.Add(time.Duration(i)*time.Millisecond), frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestReplayTimeSales(t *testing.T) {
+	const n = 10
+	content := make([]map[string]interface{}, n)
+	for i := range content {
+		content[i] = map[string]interface{}{"key": "AAPL", "1": 1597411800000 + i, "2": 100 + float64(i), "3": 10, "4": i + 1}
+	}
+
+	rp, err := td.NewReplay(recording(t, "TIMESALE_EQUITY", content...), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	ts, err := rp.TimeSales(context.Background(), td.EquityTimeSale, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp.Start()
+
+	// the replay ends before anything is read, the buffered trades must still be delivered
+	select {
+	case <-rp.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the end of the replay")
+	}
+
+	var got []*td.Trade
+	for tr := range ts.C {
+		got = append(got, tr)
+	}
+	if len(got) != n {
+		t.Fatalf("expected %d trades, got %d", n, len(got))
+	}
+	for i, tr := range got {
+		if tr.Sequence != int64(i+1) || tr.Price != 100+float64(i) || tr.Gap != 0 {
+			t.Fatalf("unexpected trade %d: %+v", i, tr)
+		}
+	}
+
+	// closing the subscription ends C without waiting for a reader
+	if rp, err = td.NewReplay(recording(t, "TIMESALE_EQUITY", content...), 0); err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+	if ts, err = rp.TimeSales(context.Background(), td.EquityTimeSale, "AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	rp.Start()
+	<-rp.Done()
+	ts.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		for range ts.C {
+		}
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the closed subscription")
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	srv, s, done := newTestStreamer(t)
+	defer done()
+
+	var buf bytes.Buffer
+	rec, err := td.NewRecorder(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetRecorder(rec)
+
+	ts, err := s.TimeSales(context.Background(), td.EquityTimeSale, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var live []*td.Trade
+	for i := 0; i < 3; i++ {
+		srv.Push("TIMESALE_EQUITY", tdtest.Content{"key": "AAPL", "1": 1597411800000 + i, "2": 100 + float64(i), "3": 10, "4": i*2 + 1})
+		select {
+		case tr := <-ts.C:
+			live = append(live, tr)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a trade")
+		}
+	}
+
+	s.Close()
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rp, err := td.NewReplay(bytes.NewReader(buf.Bytes()), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	rts, err := rp.TimeSales(context.Background(), td.EquityTimeSale, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp.Start()
+
+	var replayed []*td.Trade
+	for tr := range rts.C {
+		replayed = append(replayed, tr)
+	}
+	if !reflect.DeepEqual(live, replayed) {
+		t.Fatalf("expected %+v, got %+v", live, replayed)
+	}
+	if live[1].Gap != 1 {
+		t.Fatalf("expected a gap, got %+v", live[1])
+	}
+	if err := rp.Err(); err != io.EOF {
+		t.Fatalf("expected io.EOF at the end of the recording, got %v", err)
+	}
+
+	if _, err := td.NewReplay(bytes.NewReader([]byte("not a recording")), 0); err != td.ErrInvalidRecording {
+		t.Fatalf("expected ErrInvalidRecording, got %v", err)
+	}
+}
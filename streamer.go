@@ -2,18 +2,217 @@ package td
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"golang.org/x/xerrors"
 )
 
+const (
+	minReconnectDelay       = time.Second
+	maxReconnectDelay       = time.Minute
+	loginTimeout            = 30 * time.Second
+	defaultHeartbeatTimeout = 30 * time.Second
+)
+
+var (
+	ErrStaleConnection    = errors.New("no heartbeat received within the timeout")
+	ErrNoStreamerAccess   = errors.New("streamer access isn't authorized")
+	ErrNoSubscriptionKeys = errors.New("no streamer subscription keys")
+	ErrStreamerClosed     = errors.New("streamer closed")
+	ErrConnectionLost     = errors.New("connection lost before a response was received")
+)
+
+// StreamerState is the connection state of a Streamer.
+type StreamerState int32
+
+const (
+	StreamerConnected StreamerState = iota
+	StreamerReconnecting
+	StreamerClosed
+)
+
+func (st StreamerState) String() string {
+	switch st {
+	case StreamerConnected:
+		return "connected"
+	case StreamerReconnecting:
+		return "reconnecting"
+	case StreamerClosed:
+		return "closed"
+	default:
+		return "StreamerState(" + strconv.Itoa(int(st)) + ")"
+	}
+}
+
+// StreamerOptions configures a Streamer.
+type StreamerOptions struct {
+	// QOS is the initial quality of service level, see Streamer.SetQoS.
+	QOS int
+
+	// AccountID is the account used for the login credentials, it defaults to Client.DefaultAccountID,
+	// then the primary account, then the first account with streamer access.
+	AccountID string
+}
+
+// Streamer returns a connected streamer, if the connection drops it will automatically reconnect with fresh
+// credentials and resubscribe to all the active subscriptions.
 func (c *Client) Streamer(ctx context.Context, qos int) (s *Streamer, err error) {
+	return c.StreamerWithOptions(ctx, &StreamerOptions{QOS: qos})
+}
+
+// StreamerWithOptions is like Streamer but allows choosing the account used to log in, opts can be nil.
+func (c *Client) StreamerWithOptions(ctx context.Context, opts *StreamerOptions) (s *Streamer, err error) {
+	if opts == nil {
+		opts = &StreamerOptions{}
+	}
+	accID := opts.AccountID
+	if accID == "" {
+		accID = c.DefaultAccountID
+	}
+
+	s = newStreamer(c, nil)
+	s.qos, s.wantAccID, s.hbTimeout = opts.QOS, accID, defaultHeartbeatTimeout
+	if err = s.connect(ctx); err != nil {
+		return nil, err
+	}
+
+	go s.run()
+	return
+}
+
+func newStreamer(c *Client, conn streamConn) *Streamer {
+	s := &Streamer{
+		c:    c,
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if conn != nil {
+		s.conn, s.connDone = conn, make(chan struct{})
+	}
+	return s
+}
+
+// streamConn is the part of *websocket.Conn used by the streamer, it allows replaying recorded sessions.
+type streamConn interface {
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteJSON(v interface{}) error
+	SetReadDeadline(t time.Time) error
+	Close() error
+}
+
+type Streamer struct {
+	mux       sync.Mutex
+	c         *Client
+	conn      streamConn
+	connDone  chan struct{} // closed when conn's read loop exits
+	qos       int
+	wantAccID string
+	accID     string
+	appID     string
+	keys      []string
+	reqID     int64
+	m         sync.Map
+	subMux    sync.RWMutex
+	cmdMux    sync.Mutex
+	svcs      map[string]*streamService
+	state     streamState
+	status    int32
+	closed    int32
+	closeOnce sync.Once
+	quit      chan struct{} // closed by Close
+	done      chan struct{} // closed once the streamer is shut down
+	err       error
+	hbTimeout time.Duration
+	lastHB    int64
+	lastMsg   sync.Map
+	rec       *Recorder
+
+	OnData     func(data []Any)
+	OnResponse func(code int, message string)
+
+	// OnStateChange is called every time the connection state changes, err is the reason of the disconnect if any.
+	OnStateChange func(state StreamerState, err error)
+}
+
+// State returns the current connection state.
+func (s *Streamer) State() StreamerState {
+	return StreamerState(atomic.LoadInt32(&s.status))
+}
+
+func (s *Streamer) setState(state StreamerState, err error) {
+	if StreamerState(atomic.SwapInt32(&s.status, int32(state))) == state {
+		return
+	}
+	if s.OnStateChange != nil {
+		s.OnStateChange(state, err)
+	}
+}
+
+// SetHeartbeatTimeout sets how long to wait for a heartbeat before the connection is considered stale,
+// torn down and reconnected, d <= 0 disables the check.
+func (s *Streamer) SetHeartbeatTimeout(d time.Duration) {
+	s.mux.Lock()
+	s.hbTimeout = d
+	s.mux.Unlock()
+}
+
+// LastHeartbeat returns when the last heartbeat was received.
+func (s *Streamer) LastHeartbeat() time.Time {
+	if n := atomic.LoadInt64(&s.lastHB); n > 0 {
+		return time.Unix(0, n)
+	}
+	return time.Time{}
+}
+
+// LastMessage returns when the last data message for the given service was received.
+func (s *Streamer) LastMessage(service string) time.Time {
+	if v, ok := s.lastMsg.Load(service); ok {
+		return v.(time.Time)
+	}
+	return time.Time{}
+}
+
+// SetRecorder records every frame received from now on to r, nil stops recording.
+func (s *Streamer) SetRecorder(r *Recorder) {
+	s.mux.Lock()
+	s.rec = r
+	s.mux.Unlock()
+}
+
+func (s *Streamer) isClosed() bool {
+	return atomic.LoadInt32(&s.closed) == 1
+}
+
+// Done returns a channel that is closed once the streamer is shut down and all the subscription channels are closed.
+func (s *Streamer) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns nil until Done is closed, then ErrStreamerClosed if Close was called or the error that
+// stopped the streamer, for example io.EOF at the end of a replay.
+func (s *Streamer) Err() error {
+	select {
+	case <-s.done:
+	default:
+		return nil
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.err
+}
+
+// connect fetches fresh credentials, dials the streamer socket and logs in.
+func (s *Streamer) connect(ctx context.Context) (err error) {
 	type loginReq struct {
 		Credential string `json:"credential"`
 		Token      string `json:"token"`
@@ -22,11 +221,22 @@ func (c *Client) Streamer(ctx context.Context, qos int) (s *Streamer, err error)
 	}
 
 	var up *UserPrincipal
-	if up, err = c.UserPrincipals(ctx, AllUserPrincipalFields); err != nil {
+	if up, err = s.c.UserPrincipals(ctx, AllUserPrincipalFields); err != nil {
+		return
+	}
+
+	s.mux.Lock()
+	wantAccID := s.wantAccID
+	s.mux.Unlock()
+
+	var (
+		acc  *Accounts
+		keys []string
+	)
+	if acc, keys, err = streamerAccount(up, wantAccID); err != nil {
 		return
 	}
 
-	acc := up.Accounts[0]
 	si := up.StreamerInfo
 	tsInMS := si.TokenTimestamp.Time().Unix() * 1000
 	creds := url.Values{
@@ -47,47 +257,296 @@ func (c *Client) Streamer(ctx context.Context, qos int) (s *Streamer, err error)
 		conn *websocket.Conn
 		resp *http.Response
 	)
-	if conn, resp, err = websocket.DefaultDialer.DialContext(ctx, "wss://"+si.StreamerSocketUrl+"/ws", nil); err != nil {
+	if conn, resp, err = websocket.DefaultDialer.DialContext(ctx, streamerURL(si.StreamerSocketUrl), nil); err != nil {
 		return
 	}
 
 	if resp.StatusCode != 101 {
-		err = xerrors.Errorf("%d: %s", resp.StatusCode, resp.Status)
+		conn.Close()
+		return xerrors.Errorf("%d: %s", resp.StatusCode, resp.Status)
+	}
+
+	s.mux.Lock()
+	s.accID, s.appID, s.keys = acc.AccountID, si.AppID, keys
+	req, id := s.makeRequest("ADMIN", "LOGIN", loginReq{
+		Credential: creds.Encode(),
+		Token:      si.Token,
+		Version:    "1.0",
+		QOSLevel:   s.qos,
+	})
+	s.mux.Unlock()
+
+	if err = login(conn, req, id); err != nil {
+		conn.Close()
 		return
 	}
 
-	s = &Streamer{conn: conn, accID: acc.AccountID, appID: si.AppID, key: up.StreamerSubscriptionKeys.Keys[0].Key}
+	s.mux.Lock()
+	if s.isClosed() {
+		s.mux.Unlock()
+		conn.Close()
+		return ErrStreamerClosed
+	}
+	s.conn, s.connDone = conn, make(chan struct{})
+	s.mux.Unlock()
+	atomic.StoreInt64(&s.lastHB, time.Now().UnixNano())
+	s.setState(StreamerConnected, nil)
+	return
+}
 
-	defer func() {
+// streamerAccount returns the account used to log in and the subscription keys, if accountID is empty
+// the primary account is preferred, falling back to the first account with streamer access.
+func streamerAccount(up *UserPrincipal, accountID string) (acc *Accounts, keys []string, err error) {
+	if up.StreamerInfo == nil {
+		return nil, nil, ErrNoStreamerAccess
+	}
+
+	if up.StreamerSubscriptionKeys != nil {
+		for _, k := range up.StreamerSubscriptionKeys.Keys {
+			if k != nil && k.Key != "" {
+				keys = append(keys, k.Key)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil, ErrNoSubscriptionKeys
+	}
+
+	hasAccess := func(a *Accounts) bool {
+		return a != nil && a.Authorizations != nil && a.Authorizations.StreamerAccess
+	}
+
+	find := func(id string) *Accounts {
+		for _, a := range up.Accounts {
+			if a != nil && a.AccountID == id {
+				return a
+			}
+		}
+		return nil
+	}
+
+	if accountID != "" {
+		if acc = find(accountID); acc == nil {
+			return nil, nil, xerrors.Errorf("account %s: not found", accountID)
+		}
+		if !hasAccess(acc) {
+			return nil, nil, xerrors.Errorf("account %s: %w", accountID, ErrNoStreamerAccess)
+		}
+		return acc, keys, nil
+	}
+
+	if acc = find(up.PrimaryAccountID); hasAccess(acc) {
+		return acc, keys, nil
+	}
+
+	for _, a := range up.Accounts {
+		if hasAccess(a) {
+			return a, keys, nil
+		}
+	}
+
+	return nil, nil, ErrNoStreamerAccess
+}
+
+// AccountID returns the account used to log in.
+func (s *Streamer) AccountID() string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.accID
+}
+
+// SubscriptionKeys returns the streamer subscription keys used by AccountActivity.
+func (s *Streamer) SubscriptionKeys() []string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return append([]string(nil), s.keys...)
+}
+
+// streamerURL returns the websocket url for the socket url in StreamerInfo, full urls are used as is.
+func streamerURL(socketURL string) string {
+	if strings.Contains(socketURL, "://") {
+		return socketURL
+	}
+	return "wss://" + socketURL + "/ws"
+}
+
+// login sends the login request and waits for its response before the read loop takes over the connection.
+func login(conn streamConn, req *streamRequests, id string) error {
+	conn.SetReadDeadline(time.Now().Add(loginTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	if err := conn.WriteJSON(req); err != nil {
+		return err
+	}
+
+	for {
+		_, b, err := conn.ReadMessage()
 		if err != nil {
-			s.Close()
-			s = nil
+			return err
 		}
-	}()
+		var sr streamResponse
+		if err := json.Unmarshal(b, &sr); err != nil {
+			return err
+		}
+		for _, r := range sr.Response {
+			if r.RequestID != id {
+				continue
+			}
+			if c := r.Content; c.Code != 0 {
+				return xerrors.Errorf("login error %d: %s", c.Code, c.Msg)
+			}
+			return nil
+		}
+	}
+}
 
-	go s.loop()
+// run reads from the connection until it drops, then reconnects until Close is called.
+func (s *Streamer) run() {
+	for {
+		s.mux.Lock()
+		conn, lost := s.conn, s.connDone
+		s.mux.Unlock()
 
-	err = s.sendRequest(ctx, "ADMIN", "LOGIN", loginReq{
-		Credential: creds.Encode(),
-		Token:      si.Token,
-		Version:    "1.0",
-		QOSLevel:   qos,
-	})
+		stale, done := make(chan struct{}), make(chan struct{})
+		go s.watchdog(conn, stale, done)
 
-	return
+		err := s.loop(conn)
+		close(done)
+		close(lost)
+
+		select {
+		case <-stale:
+			err = ErrStaleConnection
+		default:
+		}
+
+		// replays have no client to reconnect with
+		if s.isClosed() || s.c == nil {
+			conn.Close()
+			s.shutdown(err)
+			return
+		}
+
+		conn.Close()
+		s.setState(StreamerReconnecting, err)
+		if !s.reconnect() {
+			s.shutdown(nil)
+			return
+		}
+	}
 }
 
-type Streamer struct {
-	mux   sync.Mutex
-	conn  *websocket.Conn
-	accID string
-	appID string
-	key   string
-	reqID int64
-	m     sync.Map
+// shutdown closes all the subscriptions and marks the streamer as done, err is ignored if Close was called.
+func (s *Streamer) shutdown(err error) {
+	if s.isClosed() {
+		err = ErrStreamerClosed
+	}
 
-	OnData     func(data []Any)
-	OnResponse func(code int, message string)
+	s.mux.Lock()
+	s.err = err
+	s.mux.Unlock()
+	close(s.done)
+	s.stopSubs()
+
+	// addKeys checks done under subMux, so nothing can be registered after this
+	s.subMux.Lock()
+	svcs := s.svcs
+	s.svcs = nil
+	s.subMux.Unlock()
+
+	// an explicit Close discards the typed events, otherwise (EOF, connection errors) they are delivered
+	cancel := err == ErrStreamerClosed
+	for _, ss := range svcs {
+		for sub := range ss.subs {
+			sub.close(cancel)
+		}
+	}
+
+	if err == ErrStreamerClosed {
+		err = nil
+	}
+	s.setState(StreamerClosed, err)
+}
+
+// watchdog closes the connection if no heartbeat was received within the heartbeat timeout,
+// which makes the read loop return and run reconnect.
+func (s *Streamer) watchdog(conn streamConn, stale, done chan struct{}) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-t.C:
+			s.mux.Lock()
+			timeout := s.hbTimeout
+			s.mux.Unlock()
+			if timeout <= 0 || now.Sub(s.LastHeartbeat()) < timeout {
+				continue
+			}
+			close(stale)
+			conn.Close()
+			return
+		}
+	}
+}
+
+// reconnect keeps trying to connect with an exponential backoff, returns false if the streamer was closed meanwhile.
+func (s *Streamer) reconnect() bool {
+	delay := minReconnectDelay
+	for {
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-s.quit:
+			t.Stop()
+			return false
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), loginTimeout)
+		go func() {
+			select {
+			case <-s.quit:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		err := s.connect(ctx)
+		cancel()
+
+		if s.isClosed() {
+			return false
+		}
+
+		if err == nil {
+			s.resubscribe()
+			return true
+		}
+
+		if s.OnStateChange != nil {
+			s.OnStateChange(StreamerReconnecting, err)
+		}
+
+		if delay *= 2; delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// resubscribe replays all the active subscriptions on a new connection,
+// the responses are reported through OnResponse.
+func (s *Streamer) resubscribe() {
+	s.subMux.RLock()
+	defer s.subMux.RUnlock()
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for svc, ss := range s.svcs {
+		params := StreamRequestParams{Keys: strings.Join(sortedKeys(ss.keys), ","), Fields: ss.fields}
+		req, _ := s.makeRequest(svc, "SUBS", params)
+		if err := s.conn.WriteJSON(req); err != nil {
+			return
+		}
+	}
 }
 
 func (s *Streamer) SetQoS(ctx context.Context, qos int) error {
@@ -97,19 +556,18 @@ func (s *Streamer) SetQoS(ctx context.Context, qos int) error {
 	if qos < 0 || qos > 5 {
 		return xerrors.Errorf("%d is out of range, the range is 0 to 5", qos)
 	}
-	return s.sendRequest(ctx, "ADMIN", "QOS", qosReq{qos})
+	if err := s.sendRequest(ctx, "ADMIN", "QOS", qosReq{qos}); err != nil {
+		return err
+	}
+	s.mux.Lock()
+	s.qos = qos
+	s.mux.Unlock()
+	return nil
 }
 
 type StreamRequestParams struct {
 	Keys   string `json:"keys"`
-	Fields string `json:"fields"`
-}
-
-func (s *Streamer) AccountActivity(ctx context.Context) (<-chan Any, error) {
-	const svc = "ACCT_ACTIVITY"
-	const fields = "0,1,2,3"
-
-	return s.Subscribe(ctx, svc, StreamRequestParams{Keys: s.key, Fields: fields})
+	Fields string `json:"fields,omitempty"`
 }
 
 type ChartType string
@@ -120,7 +578,7 @@ const (
 	OptionsChart ChartType = "CHART_OPTIONS"
 )
 
-func (s *Streamer) Chart(ctx context.Context, chartType ChartType, symbols ...string) (<-chan Any, error) {
+func (s *Streamer) Chart(ctx context.Context, chartType ChartType, symbols ...string) (*Subscription, error) {
 	const allFields = "0,1,2,3,4,5,6,7"
 	return s.Subscribe(ctx, string(chartType), StreamRequestParams{
 		Keys:   strings.Join(symbols, ","),
@@ -128,42 +586,44 @@ func (s *Streamer) Chart(ctx context.Context, chartType ChartType, symbols ...st
 	})
 }
 
-func (s *Streamer) Subscribe(ctx context.Context, svc string, params StreamRequestParams) (<-chan Any, error) {
-	v, _ := s.m.LoadOrStore(svc, make(chan Any, 256))
-	if err := s.sendRequest(ctx, svc, "SUBS", params); err != nil {
-		return nil, err
-	}
-	ch := v.(chan Any)
-	return ch, nil
-}
-
-// Unsubcribe will close any channels listening for svc and try to run
-// the UNSUBS command, which fails because, well reasons...
-func (s *Streamer) Unsubcribe(ctx context.Context, svc string) error {
-	ch, _ := s.m.LoadAndDelete(svc)
-	if ch, ok := ch.(chan Any); ok {
-		close(ch)
-	}
+// Close logs out and closes the connection, it is safe to call multiple times and from multiple goroutines.
+// All the subscription channels are closed once Done is closed.
+func (s *Streamer) Close() (err error) {
+	s.closeOnce.Do(func() {
+		atomic.StoreInt32(&s.closed, 1)
+		close(s.quit)
 
-	// this always returns error 21
-	// return s.sendRequest(ctx, svc, "UNSUBS", nil)
-	return nil // s.sendRequest(ctx, svc, "UNSUBS", nil)
-}
-
-func (s *Streamer) Close() error {
-	req, _ := s.makeRequest("ADMIN", "LOGOUT", nil)
-	s.conn.WriteJSON(req)
-	return s.conn.Close()
+		s.mux.Lock()
+		defer s.mux.Unlock()
+		if s.conn == nil || s.State() != StreamerConnected {
+			return
+		}
+		req, _ := s.makeRequest("ADMIN", "LOGOUT", nil)
+		s.conn.WriteJSON(req)
+		err = s.conn.Close()
+	})
+	return
 }
 
-func (s *Streamer) loop() {
+func (s *Streamer) loop(conn streamConn) error {
 	for {
+		_, b, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		s.mux.Lock()
+		rec := s.rec
+		s.mux.Unlock()
+		if rec != nil {
+			rec.Record(time.Now(), b)
+		}
+
 		var sr streamResponse
-		if err := s.conn.ReadJSON(&sr); err != nil {
-			break
+		if err := json.Unmarshal(b, &sr); err != nil {
+			continue
 		}
-		// j, _ := json.Marshal(&sr)
-		// log.Printf("%s", j)
+		// log.Printf("%s", b)
 
 		for _, r := range sr.Response {
 			if v, ok := s.m.Load(r.RequestID); ok {
@@ -179,16 +639,32 @@ func (s *Streamer) loop() {
 			}
 		}
 
-		for _, d := range sr.Data {
-			if d.Service != "" {
-				if v, ok := s.m.Load(d.Service); ok {
+		// snapshots are replies to GET requests, the request id is in field 0 of the content
+		for _, d := range sr.Snapshot {
+			for _, c := range d.Content {
+				id := fmt.Sprint(anyToRecord(c)["0"])
+				if v, ok := s.m.Load(snapshotKey(id)); ok {
 					if ch, ok := v.(chan Any); ok {
-						for _, c := range d.Content {
-							ch <- c
-						}
+						ch <- c
+						s.m.Delete(snapshotKey(id))
 					}
 				}
 			}
+		}
+
+		for _, n := range sr.Notify {
+			if n.Heartbeat != "" {
+				atomic.StoreInt64(&s.lastHB, time.Now().UnixNano())
+			}
+		}
+
+		for _, d := range sr.Data {
+			if d.Service != "" {
+				s.lastMsg.Store(d.Service, time.Now())
+				for _, c := range d.Content {
+					s.dispatch(d.Service, c)
+				}
+			}
 
 			if s.OnData != nil {
 				s.OnData(d.Content)
@@ -197,6 +673,7 @@ func (s *Streamer) loop() {
 	}
 }
 
+// makeRequest must be called with s.mux held.
 func (s *Streamer) makeRequest(service, cmd string, params interface{}) (*streamRequests, string) {
 	id := strconv.FormatInt(atomic.AddInt64(&s.reqID, 1), 10)
 	return &streamRequests{
@@ -214,27 +691,71 @@ func (s *Streamer) makeRequest(service, cmd string, params interface{}) (*stream
 }
 
 func (s *Streamer) sendRequest(ctx context.Context, service, cmd string, params interface{}) (err error) {
-	req, id := s.makeRequest(service, cmd, params)
+	_, err = s.request(ctx, service, cmd, params, false)
+	return
+}
+
+// request sends a request and waits for its response, if snapshot is set it also waits for
+// the snapshot data with the same request id and returns it.
+func (s *Streamer) request(ctx context.Context, service, cmd string, params interface{}, snapshot bool) (data Any, err error) {
 	ch := make(chan *streamDataResponse, 1)
+	var snap chan Any
+
+	select {
+	case <-s.quit:
+		return data, ErrStreamerClosed
+	default:
+	}
+
 	s.mux.Lock()
+	lost := s.connDone
+	req, id := s.makeRequest(service, cmd, params)
 	s.m.Store(id, ch)
+	if snapshot {
+		snap = make(chan Any, 1)
+		s.m.Store(snapshotKey(id), snap)
+	}
 	err = s.conn.WriteJSON(req)
 	s.mux.Unlock()
+
+	defer func() {
+		s.m.Delete(id)
+		if snapshot {
+			s.m.Delete(snapshotKey(id))
+		}
+	}()
+
 	if err != nil {
 		return
 	}
-	select {
-	case r := <-ch:
-		if c := r.Content; c.Code != 0 {
-			err = xerrors.Errorf("error %d: %s", c.Code, c.Msg)
+
+	for {
+		select {
+		case r := <-ch:
+			if c := r.Content; c.Code != 0 {
+				err = xerrors.Errorf("error %d: %s", c.Code, c.Msg)
+				return
+			}
+			if !snapshot {
+				return
+			}
+			ch = nil
+		case data = <-snap:
+			return
+		case <-lost:
+			if err = ErrConnectionLost; s.isClosed() {
+				err = ErrStreamerClosed
+			}
+			return
+		case <-ctx.Done():
+			err = ctx.Err()
 			return
 		}
-	case <-ctx.Done():
-		return ctx.Err()
 	}
-	return
 }
 
+func snapshotKey(id string) string { return "snapshot:" + id }
+
 type streamRequests struct {
 	Requests []streamRequest `json:"requests,omitempty"`
 }
@@ -251,6 +772,7 @@ type streamRequest struct {
 type streamResponse struct {
 	Response []*streamDataResponse `json:"response,omitempty"`
 	Data     []*streamSubResponse  `json:"data,omitempty"`
+	Snapshot []*streamSubResponse  `json:"snapshot,omitempty"`
 	Notify   []struct {
 		Heartbeat string `json:"heartbeat,omitempty"`
 	} `json:"notify,omitempty"`
@@ -0,0 +1,91 @@
+package td
+
+import (
+	"encoding/json"
+	"sync"
+
+	"go.oneofone.dev/anyx"
+)
+
+// streamState keeps the merged fields for every service/key pair, TD only sends the changed fields
+// after the first message for a key.
+type streamState struct {
+	mux sync.RWMutex
+	m   map[string]map[string]map[string]interface{}
+}
+
+// merge applies the delta to the current record of svc/key and returns a copy of the full record.
+func (st *streamState) merge(svc string, delta map[string]interface{}) (full map[string]interface{}) {
+	key, _ := delta["key"].(string)
+
+	st.mux.Lock()
+	defer st.mux.Unlock()
+
+	if st.m == nil {
+		st.m = map[string]map[string]map[string]interface{}{}
+	}
+
+	keys := st.m[svc]
+	if keys == nil {
+		keys = map[string]map[string]interface{}{}
+		st.m[svc] = keys
+	}
+
+	rec := keys[key]
+	if rec == nil {
+		rec = make(map[string]interface{}, len(delta))
+		keys[key] = rec
+	}
+
+	for k, v := range delta {
+		rec[k] = v
+	}
+
+	return copyRecord(rec)
+}
+
+func (st *streamState) get(svc, key string) (map[string]interface{}, bool) {
+	st.mux.RLock()
+	defer st.mux.RUnlock()
+	rec, ok := st.m[svc][key]
+	if !ok {
+		return nil, false
+	}
+	return copyRecord(rec), true
+}
+
+func (st *streamState) delete(svc string, keys ...string) {
+	st.mux.Lock()
+	defer st.mux.Unlock()
+	if len(keys) == 0 {
+		delete(st.m, svc)
+		return
+	}
+	for _, k := range keys {
+		delete(st.m[svc], k)
+	}
+}
+
+func copyRecord(rec map[string]interface{}) map[string]interface{} {
+	cp := make(map[string]interface{}, len(rec))
+	for k, v := range rec {
+		cp[k] = v
+	}
+	return cp
+}
+
+// anyToRecord converts a streamed content item to a plain map.
+func anyToRecord(v Any) (rec map[string]interface{}) {
+	json.Unmarshal(marshalAny(v), &rec)
+	return
+}
+
+// Snapshot returns the current merged record for the given service and key (usually a symbol),
+// it contains every field received so far, not just the last update.
+func (s *Streamer) Snapshot(service, key string) (v Any, ok bool) {
+	var rec map[string]interface{}
+	if rec, ok = s.state.get(service, key); ok {
+		v = anyx.Value(rec)
+	}
+	return
+}
@@ -0,0 +1,718 @@
+package td_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go.oneofone.dev/td"
+	"go.oneofone.dev/td/tdtest"
+)
+
+func newTestStreamer(t *testing.T) (*tdtest.Server, *td.Streamer, func()) {
+	srv := tdtest.NewServer()
+	c, err := srv.Client(context.Background())
+	if err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s, err := c.Streamer(ctx, 0)
+	if err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+
+	return srv, s, func() {
+		s.Close()
+		srv.Close()
+	}
+}
+
+func recvKey(t *testing.T, ch <-chan td.Any) string {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v.Get("key").String(false)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for data")
+		return ""
+	}
+}
+
+func waitFor(t *testing.T, what string, fn func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if fn() {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestStreamerSubscribe(t *testing.T) {
+	srv, s, done := newTestStreamer(t)
+	defer done()
+	ctx := context.Background()
+
+	srv.Script("QUOTE", tdtest.Content{"key": "AAPL", "1": 100.5, "2": 100.6})
+
+	sub, err := s.Subscribe(ctx, "QUOTE", td.StreamRequestParams{Keys: "AAPL,MSFT", Fields: "0,1,2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if k := recvKey(t, sub.C); k != "AAPL" {
+		t.Fatalf("expected the scripted AAPL quote, got %q", k)
+	}
+
+	if keys, fields := srv.Subscribed("QUOTE"); !reflect.DeepEqual(keys, []string{"AAPL", "MSFT"}) || fields != "0,1,2" {
+		t.Fatalf("unexpected subscription: %v %q", keys, fields)
+	}
+
+	srv.Push("QUOTE", tdtest.Content{"key": "GOOG", "1": 1}, tdtest.Content{"key": "MSFT", "1": 200})
+	if k := recvKey(t, sub.C); k != "MSFT" {
+		t.Fatalf("expected MSFT, got %q", k)
+	}
+
+	if err := sub.Add(ctx, "GOOG"); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := srv.Subscribed("QUOTE"); !reflect.DeepEqual(keys, []string{"AAPL", "GOOG", "MSFT"}) {
+		t.Fatalf("unexpected keys after add: %v", keys)
+	}
+
+	if err := sub.Unsubscribe(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := srv.Subscribed("QUOTE"); len(keys) != 0 {
+		t.Fatalf("expected no keys, got %v", keys)
+	}
+}
+
+func TestStreamerReconnect(t *testing.T) {
+	srv, s, done := newTestStreamer(t)
+	defer done()
+	ctx := context.Background()
+
+	sub, err := s.Subscribe(ctx, "QUOTE", td.StreamRequestParams{Keys: "AAPL", Fields: "0,1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.DropConnections()
+	waitFor(t, "the resubscription", func() bool {
+		keys, _ := srv.Subscribed("QUOTE")
+		return srv.Logins() == 2 && len(keys) == 1
+	})
+
+	srv.Push("QUOTE", tdtest.Content{"key": "AAPL", "1": 100})
+	if k := recvKey(t, sub.C); k != "AAPL" {
+		t.Fatalf("expected AAPL, got %q", k)
+	}
+}
+
+func TestStreamerLoginDenied(t *testing.T) {
+	srv := tdtest.NewServer()
+	defer srv.Close()
+	srv.OnRequest = func(req *tdtest.Request) (int, string) {
+		if req.Command == "LOGIN" {
+			return tdtest.CodeLoginDenied, "Login denied"
+		}
+		return tdtest.CodeSuccess, ""
+	}
+
+	c, err := srv.Client(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if s, err := c.Streamer(ctx, 0); err == nil {
+		s.Close()
+		t.Fatal("expected a login error")
+	}
+}
+
+func TestStreamerAccounts(t *testing.T) {
+	srv := tdtest.NewServer()
+	defer srv.Close()
+
+	up := srv.Principal
+	up.Accounts = append(up.Accounts,
+		&td.Accounts{AccountID: "222", Authorizations: &td.Authorizations{StreamerAccess: true}},
+		&td.Accounts{AccountID: "333", Authorizations: &td.Authorizations{}},
+	)
+	up.StreamerSubscriptionKeys.Keys = append(up.StreamerSubscriptionKeys.Keys, &td.Keys{Key: "second-key"})
+
+	c, err := srv.Client(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := c.StreamerWithOptions(ctx, &td.StreamerOptions{AccountID: "333"}); !errors.Is(err, td.ErrNoStreamerAccess) {
+		t.Fatalf("expected ErrNoStreamerAccess, got %v", err)
+	}
+
+	if _, err := c.StreamerWithOptions(ctx, &td.StreamerOptions{AccountID: "444"}); err == nil {
+		t.Fatal("expected an error for an unknown account")
+	}
+
+	s, err := c.StreamerWithOptions(ctx, &td.StreamerOptions{AccountID: "222"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if id := s.AccountID(); id != "222" {
+		t.Fatalf("expected account 222, got %s", id)
+	}
+
+	sub, err := s.AccountActivity(ctx, "222")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if keys, _ := srv.Subscribed("ACCT_ACTIVITY"); !reflect.DeepEqual(keys, []string{"second-key", "test-subscription-key"}) {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+
+	srv.Push("ACCT_ACTIVITY",
+		tdtest.Content{"key": "test-subscription-key", "1": "123456789", "2": "OrderEntryRequest", "3": ""},
+		tdtest.Content{"key": "second-key", "1": "222", "2": "OrderEntryRequest", "3": ""},
+	)
+
+	select {
+	case ev := <-sub.C:
+		if id := ev.Activity().AccountID; id != "222" {
+			t.Fatalf("expected an event for 222, got %s", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the account activity")
+	}
+}
+
+func TestStreamerNoAccess(t *testing.T) {
+	srv := tdtest.NewServer()
+	defer srv.Close()
+	srv.Principal.Accounts[0].Authorizations.StreamerAccess = false
+
+	c, err := srv.Client(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Streamer(context.Background(), 0); !errors.Is(err, td.ErrNoStreamerAccess) {
+		t.Fatalf("expected ErrNoStreamerAccess, got %v", err)
+	}
+
+	srv.Principal.Accounts[0].Authorizations.StreamerAccess = true
+	srv.Principal.StreamerSubscriptionKeys.Keys = nil
+	if _, err := c.Streamer(context.Background(), 0); !errors.Is(err, td.ErrNoSubscriptionKeys) {
+		t.Fatalf("expected ErrNoSubscriptionKeys, got %v", err)
+	}
+}
+
+func TestStreamerClose(t *testing.T) {
+	_, s, done := newTestStreamer(t)
+	defer done()
+	ctx := context.Background()
+
+	sub, err := s.Subscribe(ctx, "QUOTE", td.StreamRequestParams{Keys: "AAPL", Fields: "0,1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Err() != nil {
+		t.Fatalf("unexpected error before close: %v", s.Err())
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Close()
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case <-s.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the shutdown")
+	}
+
+	if err := s.Err(); err != td.ErrStreamerClosed {
+		t.Fatalf("expected ErrStreamerClosed, got %v", err)
+	}
+
+	if _, ok := <-sub.C; ok {
+		t.Fatal("expected the subscription channel to be closed")
+	}
+
+	if _, err := s.Subscribe(ctx, "QUOTE", td.StreamRequestParams{Keys: "MSFT"}); err != td.ErrStreamerClosed {
+		t.Fatalf("expected ErrStreamerClosed, got %v", err)
+	}
+
+	if st := s.State(); st != td.StreamerClosed {
+		t.Fatalf("expected the closed state, got %v", st)
+	}
+}
+
+func TestStreamerConnectionLost(t *testing.T) {
+	srv, s, done := newTestStreamer(t)
+	defer done()
+
+	release := make(chan struct{})
+	defer close(release)
+	srv.OnRequest = func(req *tdtest.Request) (int, string) {
+		if req.Service == "QUOTE" {
+			<-release
+		}
+		return tdtest.CodeSuccess, ""
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := s.Subscribe(context.Background(), "QUOTE", td.StreamRequestParams{Keys: "AAPL"})
+		errc <- err
+	}()
+
+	waitFor(t, "the request", func() bool {
+		reqs := srv.Requests()
+		return len(reqs) > 0 && reqs[len(reqs)-1].Service == "QUOTE"
+	})
+	srv.DropConnections()
+
+	select {
+	case err := <-errc:
+		if err != td.ErrConnectionLost {
+			t.Fatalf("expected ErrConnectionLost, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the pending request to fail")
+	}
+}
+
+func TestStreamerQuoteEvents(t *testing.T) {
+	srv, s, done := newTestStreamer(t)
+	defer done()
+
+	sub, err := s.QuoteEvents(context.Background(), "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Push("QUOTE", tdtest.Content{"key": "AAPL", "1": 100.1, "2": 100.2, "3": 100.15, "49": 100.15, "50": 1597411800000})
+	ev := recvQuoteEvent(t, sub.C)
+	eq, ok := ev.Quote.(*td.EquityQuote)
+	if !ok || ev.Prev != nil || eq.BidPrice != 100.1 || eq.QuoteTime().Unix() != 1597411800 {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+
+	// deltas are merged with the previous fields
+	srv.Push("QUOTE", tdtest.Content{"key": "AAPL", "2": 100.3})
+	ev = recvQuoteEvent(t, sub.C)
+	if b := ev.Quote.Base(); b.BidPrice != 100.1 || b.AskPrice != 100.3 || ev.Prev.Base().AskPrice != 100.2 {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+
+	// a lagging consumer still gets every update with the right previous quote
+	srv.Push("QUOTE", tdtest.Content{"key": "AAPL", "2": 100.4})
+	srv.Push("QUOTE", tdtest.Content{"key": "AAPL", "2": 100.4}) // no change, skipped
+	srv.Push("QUOTE", tdtest.Content{"key": "AAPL", "2": 100.5})
+	srv.Push("QUOTE", tdtest.Content{"key": "AAPL", "1": 100.2})
+	waitFor(t, "the deltas", func() bool { return len(sub.C) == 3 })
+	for _, exp := range [][4]float64{{100.1, 100.4, 100.1, 100.3}, {100.1, 100.5, 100.1, 100.4}, {100.2, 100.5, 100.1, 100.5}} {
+		ev = recvQuoteEvent(t, sub.C)
+		b, pb := ev.Quote.Base(), ev.Prev.Base()
+		if b.BidPrice != exp[0] || b.AskPrice != exp[1] || pb.BidPrice != exp[2] || pb.AskPrice != exp[3] {
+			t.Fatalf("unexpected event, expected %v: %+v", exp, ev)
+		}
+	}
+	select {
+	case ev := <-sub.C:
+		t.Fatalf("unexpected event: %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestStreamerUnsubscribeBlocked(t *testing.T) {
+	srv, s, done := newTestStreamer(t)
+	defer done()
+	ctx := context.Background()
+
+	for _, unsub := range []func(sub *td.Subscription) error{
+		func(sub *td.Subscription) error { return sub.Unsubscribe(ctx) },
+		func(sub *td.Subscription) error { return s.Unsubscribe(ctx, "QUOTE") },
+	} {
+		sub, err := s.SubscribeWithOptions(ctx, "QUOTE", td.StreamRequestParams{Keys: "AAPL", Fields: "0,1"},
+			&td.SubscribeOptions{BufferSize: 1, Policy: td.BackpressureBlock})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// nobody reads, the second message blocks the streamer
+		srv.Push("QUOTE", tdtest.Content{"key": "AAPL", "1": 1}, tdtest.Content{"key": "AAPL", "1": 2}, tdtest.Content{"key": "AAPL", "1": 3})
+		waitFor(t, "the buffer to fill up", func() bool { return len(sub.C) == 1 })
+		time.Sleep(50 * time.Millisecond)
+
+		errc := make(chan error, 1)
+		go func() { errc <- unsub(sub) }()
+		select {
+		case err := <-errc:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the unsubscription")
+		}
+
+		for range sub.C {
+		}
+	}
+
+	// the streamer isn't stuck
+	sub, err := s.Subscribe(ctx, "QUOTE", td.StreamRequestParams{Keys: "MSFT", Fields: "0,1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Push("QUOTE", tdtest.Content{"key": "MSFT", "1": 1})
+	if k := recvKey(t, sub.C); k != "MSFT" {
+		t.Fatalf("expected MSFT, got %q", k)
+	}
+}
+
+func TestStreamerMerge(t *testing.T) {
+	srv, s, done := newTestStreamer(t)
+	defer done()
+	ctx := context.Background()
+
+	sub, err := s.Subscribe(ctx, "QUOTE", td.StreamRequestParams{Keys: "AAPL", Fields: "0,1,2,3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recv := func() td.Any {
+		t.Helper()
+		select {
+		case v := <-sub.C:
+			return v
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for data")
+			return td.Any{}
+		}
+	}
+
+	srv.Push("QUOTE", tdtest.Content{"key": "AAPL", "1": 100.1, "2": 100.2, "3": 100.15})
+	recv()
+
+	// by default only the changed fields are delivered
+	srv.Push("QUOTE", tdtest.Content{"key": "AAPL", "2": 100.3})
+	if v := recv(); v.Get("2").String(false) != "100.3" || v.Get("1").String(false) != "" {
+		t.Fatalf("expected only the delta, got %v", v)
+	}
+
+	snap, ok := s.Snapshot("QUOTE", "AAPL")
+	if !ok || snap.Get("1").String(false) != "100.1" || snap.Get("2").String(false) != "100.3" || snap.Get("3").String(false) != "100.15" {
+		t.Fatalf("unexpected snapshot: %v %v", snap, ok)
+	}
+	if _, ok := s.Snapshot("QUOTE", "MSFT"); ok {
+		t.Fatal("unexpected snapshot for MSFT")
+	}
+
+	// a new subscription to the same key starts with the merged record
+	sub2, err := s.Subscribe(ctx, "QUOTE", td.StreamRequestParams{Keys: "AAPL", Fields: "0,1,2,3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case v := <-sub2.C:
+		if v.Get("1").String(false) != "100.1" || v.Get("2").String(false) != "100.3" {
+			t.Fatalf("unexpected initial snapshot: %v", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the initial snapshot")
+	}
+	if err := sub2.Unsubscribe(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// FullRecords only changes the payload of the subscription that asked for it
+	full, err := s.SubscribeWithOptions(ctx, "QUOTE", td.StreamRequestParams{Keys: "AAPL", Fields: "0,1,2,3"}, &td.SubscribeOptions{FullRecords: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recvKey(t, full.C) // the initial snapshot
+
+	srv.Push("QUOTE", tdtest.Content{"key": "AAPL", "3": 100.25})
+	if v := recv(); v.Get("3").String(false) != "100.25" || v.Get("1").String(false) != "" {
+		t.Fatalf("expected only the delta, got %v", v)
+	}
+	select {
+	case v := <-full.C:
+		if v.Get("1").String(false) != "100.1" || v.Get("2").String(false) != "100.3" || v.Get("3").String(false) != "100.25" {
+			t.Fatalf("expected the full record, got %v", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the full record")
+	}
+
+	// unsubscribing forgets the state
+	if err := sub.Unsubscribe(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := full.Unsubscribe(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Snapshot("QUOTE", "AAPL"); ok {
+		t.Fatal("expected no snapshot after unsubscribing")
+	}
+}
+
+func TestStreamerStaleHeartbeat(t *testing.T) {
+	srv, s, done := newTestStreamer(t)
+	defer done()
+
+	sub, err := s.Subscribe(context.Background(), "QUOTE", td.StreamRequestParams{Keys: "AAPL", Fields: "0,1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.SetHeartbeatTimeout(time.Second)
+
+	// heartbeats keep the connection alive
+	for i := 0; i < 10; i++ {
+		srv.Heartbeat()
+		time.Sleep(200 * time.Millisecond)
+	}
+	if n := srv.Logins(); n != 1 {
+		t.Fatalf("expected a single login, got %d", n)
+	}
+	if hb := s.LastHeartbeat(); time.Since(hb) > time.Second {
+		t.Fatalf("unexpected last heartbeat: %v", hb)
+	}
+
+	// without them the connection is torn down and the subscriptions are restored
+	waitFor(t, "the reconnection", func() bool {
+		keys, _ := srv.Subscribed("QUOTE")
+		return srv.Logins() == 2 && len(keys) == 1
+	})
+
+	srv.Push("QUOTE", tdtest.Content{"key": "AAPL", "1": 100})
+	if k := recvKey(t, sub.C); k != "AAPL" {
+		t.Fatalf("expected AAPL, got %q", k)
+	}
+	if st := s.State(); st != td.StreamerConnected {
+		t.Fatalf("expected the connected state, got %v", st)
+	}
+}
+
+func TestStreamerBackpressure(t *testing.T) {
+	srv, s, done := newTestStreamer(t)
+	defer done()
+	ctx := context.Background()
+
+	// SYNC goes through after everything pushed before it was dispatched
+	syncSub, err := s.Subscribe(ctx, "QUOTE", td.StreamRequestParams{Keys: "SYNC", Fields: "0,1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flush := func() {
+		t.Helper()
+		srv.Push("QUOTE", tdtest.Content{"key": "SYNC"})
+		recvKey(t, syncSub.C)
+	}
+
+	subscribe := func(keys string, size int, policy td.BackpressurePolicy) *td.Subscription {
+		t.Helper()
+		sub, err := s.SubscribeWithOptions(ctx, "QUOTE", td.StreamRequestParams{Keys: keys, Fields: "0,1"},
+			&td.SubscribeOptions{BufferSize: size, Policy: policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sub
+	}
+
+	// drain returns key:value of everything buffered in sub and closes it
+	drain := func(sub *td.Subscription) (out []string) {
+		t.Helper()
+		for {
+			select {
+			case v := <-sub.C:
+				out = append(out, v.Get("key").String(false)+":"+v.Get("1").String(false))
+			case <-time.After(100 * time.Millisecond):
+				if err := sub.Unsubscribe(ctx); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+		}
+	}
+
+	push := func(keys ...string) {
+		content := make([]tdtest.Content, len(keys))
+		for i, k := range keys {
+			content[i] = tdtest.Content{"key": k, "1": i + 1}
+		}
+		srv.Push("QUOTE", content...)
+	}
+
+	sub := subscribe("AAPL", 2, td.BackpressureDropNewest)
+	push("AAPL", "AAPL", "AAPL", "AAPL", "AAPL")
+	flush()
+	if got := drain(sub); !reflect.DeepEqual(got, []string{"AAPL:1", "AAPL:2"}) || sub.Dropped() != 3 {
+		t.Fatalf("drop newest: unexpected %v, %d dropped", got, sub.Dropped())
+	}
+
+	sub = subscribe("AAPL", 2, td.BackpressureDropOldest)
+	push("AAPL", "AAPL", "AAPL", "AAPL", "AAPL")
+	flush()
+	if got := drain(sub); !reflect.DeepEqual(got, []string{"AAPL:4", "AAPL:5"}) || sub.Dropped() != 3 {
+		t.Fatalf("drop oldest: unexpected %v, %d dropped", got, sub.Dropped())
+	}
+
+	// every update is either delivered or merged into a pending one, the latest value of each key always makes it
+	sub = subscribe("AAPL,MSFT", 1, td.BackpressureConflate)
+	push("AAPL", "MSFT", "AAPL", "AAPL", "MSFT")
+	flush()
+	got := drain(sub)
+	if len(got)+int(sub.Dropped()) != 5 || sub.Dropped() == 0 {
+		t.Fatalf("conflate: unexpected %v, %d dropped", got, sub.Dropped())
+	}
+	last := map[string]string{}
+	for _, v := range got {
+		last[v[:4]] = v
+	}
+	if last["AAPL"] != "AAPL:4" || last["MSFT"] != "MSFT:5" {
+		t.Fatalf("conflate: expected the latest values, got %v", got)
+	}
+
+	// the streamer waits for the consumer
+	sub = subscribe("AAPL", 1, td.BackpressureBlock)
+	push("AAPL", "AAPL", "AAPL")
+	srv.Push("QUOTE", tdtest.Content{"key": "SYNC"})
+	select {
+	case <-syncSub.C:
+		t.Fatal("expected the streamer to be blocked")
+	case <-time.After(100 * time.Millisecond):
+	}
+	for i := 1; i <= 3; i++ {
+		select {
+		case v := <-sub.C:
+			if n := v.Get("1").String(false); n != strconv.Itoa(i) {
+				t.Fatalf("block: expected %d, got %s", i, n)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for data")
+		}
+	}
+	recvKey(t, syncSub.C)
+	if sub.Dropped() != 0 {
+		t.Fatalf("block: unexpected %d dropped", sub.Dropped())
+	}
+}
+
+func TestStreamerBook(t *testing.T) {
+	srv, s, done := newTestStreamer(t)
+	defer done()
+
+	bs, err := s.Book(context.Background(), td.NasdaqBook, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	level := func(price float64, size int64) map[string]interface{} {
+		return map[string]interface{}{"0": price, "1": size, "2": 1, "3": []interface{}{}}
+	}
+	srv.Push("NASDAQ_BOOK", tdtest.Content{"key": "AAPL", "1": 1597411800000,
+		"2": []interface{}{level(100.1, 300), level(100.2, 100)}, "3": []interface{}{level(100.3, 200)}})
+
+	select {
+	case u := <-bs.C:
+		if u.Symbol != "AAPL" || len(u.Bids) != 2 || len(u.Asks) != 1 {
+			t.Fatalf("unexpected update: %+v", u)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the update")
+	}
+
+	b := bs.OrderBook("AAPL")
+	if b == nil {
+		t.Fatal("expected a book for AAPL")
+	}
+	if bid, _ := b.BestBid(); bid.Price != 100.2 {
+		t.Fatalf("unexpected best bid: %+v", bid)
+	}
+	if bs.OrderBook("MSFT") != nil {
+		t.Fatal("unexpected book for MSFT")
+	}
+}
+
+func TestStreamerFuturesHistory(t *testing.T) {
+	srv, s, done := newTestStreamer(t)
+	defer done()
+
+	var (
+		mux    sync.Mutex
+		params map[string]interface{}
+	)
+	srv.OnGet = func(req *tdtest.Request) tdtest.Content {
+		if req.Service != "CHART_HISTORY_FUTURES" || req.Parameters["symbol"] != "/ES" {
+			return nil
+		}
+		mux.Lock()
+		params = req.Parameters
+		mux.Unlock()
+		return tdtest.Content{"1": "/ES", "2": 2, "3": []interface{}{
+			map[string]interface{}{"0": 1597411800000, "1": 3370.25, "2": 3372, "3": 3369.5, "4": 3371.75, "5": 1500},
+			map[string]interface{}{"0": 1597411860000, "1": 3371.75, "2": 3373, "3": 3371, "4": 3372.5, "5": 900},
+		}}
+	}
+
+	lastParams := func() map[string]interface{} {
+		mux.Lock()
+		defer mux.Unlock()
+		return params
+	}
+
+	start := time.Date(2020, 8, 14, 9, 30, 0, 0, time.UTC)
+	cs, err := s.FuturesHistory(context.Background(), "/ES", &td.FuturesHistoryParams{
+		Frequency: td.FuturesFiveMinutes,
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string]interface{}{"symbol": "/ES", "frequency": "m5", "START_TIME": "1597397400000", "END_TIME": "1597401000000"}
+	if p := lastParams(); !reflect.DeepEqual(p, exp) {
+		t.Fatalf("expected %v, got %v", exp, p)
+	}
+	if len(cs) != 2 || cs[0].Open != 3370.25 || cs[0].Close != 3371.75 || cs[0].Volume != 1500 || cs[1].High != 3373 ||
+		cs[1].Datetime.Time().Unix() != 1597411860 {
+		t.Fatalf("unexpected candles: %+v", cs)
+	}
+
+	// the default frequency is one minute
+	_, err = s.FuturesHistory(context.Background(), "/ES", nil)
+	if p := lastParams(); err != nil || p["frequency"] != "m1" || len(p) != 2 {
+		t.Fatalf("unexpected params %v: %v", p, err)
+	}
+
+	if _, err = s.FuturesHistory(context.Background(), "/NQ", nil); err == nil {
+		t.Fatal("expected an error for an unknown symbol")
+	}
+}
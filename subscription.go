@@ -0,0 +1,558 @@
+package td
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.oneofone.dev/anyx"
+)
+
+const defaultBufferSize = 256
+
+// BackpressurePolicy decides what happens when a subscription's buffer is full.
+type BackpressurePolicy int
+
+const (
+	// BackpressureBlock blocks the streamer until the consumer catches up, this stalls every other subscription.
+	BackpressureBlock BackpressurePolicy = iota
+	// BackpressureDropOldest drops the oldest buffered message to make room for the new one.
+	BackpressureDropOldest
+	// BackpressureDropNewest drops the new message.
+	BackpressureDropNewest
+	// BackpressureConflate keeps only the latest state of every key that wasn't consumed yet,
+	// pending updates for the same key are merged together.
+	BackpressureConflate
+)
+
+type SubscribeOptions struct {
+	// BufferSize is the size of the channel buffer, default is 256.
+	BufferSize int
+
+	// Policy is what to do when the buffer is full, default is BackpressureBlock.
+	Policy BackpressurePolicy
+
+	// FullRecords delivers the full merged record of a key (see Streamer.Snapshot) instead of only the changed fields.
+	FullRecords bool
+}
+
+// Subscription is a handle to a set of keys (usually symbols) of a streaming service, the data for those keys is delivered on C.
+// Multiple subscriptions can share the same service and keys, each one gets its own copy of the data.
+type Subscription struct {
+	C <-chan Any
+
+	s      *Streamer
+	svc    string
+	fields string
+	keys   map[string]struct{} // guarded by s.subMux
+
+	mux     sync.Mutex
+	ch      chan Any
+	done    chan struct{} // closed first so blocked senders give up
+	cancel  chan struct{} // closed on Unsubscribe or Close, the typed subscriptions drop their buffered events
+	stopped sync.Once
+	cancels sync.Once
+	once    sync.Once
+	closed  bool
+	policy  BackpressurePolicy
+	full    bool
+	dropped uint64
+
+	// used by BackpressureConflate
+	pending map[string]map[string]interface{}
+	order   []string
+	wake    chan struct{}
+	pumped  chan struct{}
+}
+
+// streamService tracks what is subscribed server-side for a service, the union of all the subscriptions.
+type streamService struct {
+	subs   map[*Subscription]struct{}
+	keys   map[string]int
+	fields string
+}
+
+// Service returns the name of the service this subscription is for.
+func (sub *Subscription) Service() string { return sub.svc }
+
+// Keys returns a sorted list of the keys this subscription is listening to.
+func (sub *Subscription) Keys() []string {
+	sub.s.subMux.RLock()
+	defer sub.s.subMux.RUnlock()
+	keys := make([]string, 0, len(sub.keys))
+	for k := range sub.keys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Add extends the subscription with more keys, keys that aren't subscribed yet are added with the ADD command.
+func (sub *Subscription) Add(ctx context.Context, keys ...string) error {
+	if keys = splitKeys(strings.Join(keys, ",")); len(keys) == 0 {
+		return ErrNoSymbols
+	}
+	return sub.s.addKeys(ctx, sub, keys)
+}
+
+// Remove stops delivering the given keys, keys that no other subscription needs are removed with the UNSUBS command.
+func (sub *Subscription) Remove(ctx context.Context, keys ...string) error {
+	return sub.s.removeKeys(ctx, sub, splitKeys(strings.Join(keys, ",")))
+}
+
+// Unsubscribe removes all the keys of this subscription and closes C.
+func (sub *Subscription) Unsubscribe(ctx context.Context) error {
+	sub.close(true)
+	return sub.s.removeKeys(ctx, sub, sub.Keys())
+}
+
+// Close is Unsubscribe without a context.
+func (sub *Subscription) Close() error {
+	return sub.Unsubscribe(context.Background())
+}
+
+// Dropped returns the number of messages dropped or conflated because the consumer was too slow.
+func (sub *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&sub.dropped)
+}
+
+// send delivers v according to the subscription's policy, rec is the map form of v used for conflation.
+func (sub *Subscription) send(key string, v Any, rec map[string]interface{}) {
+	sub.mux.Lock()
+	defer sub.mux.Unlock()
+	if sub.closed {
+		return
+	}
+
+	switch sub.policy {
+	case BackpressureDropNewest:
+		select {
+		case sub.ch <- v:
+		default:
+			atomic.AddUint64(&sub.dropped, 1)
+		}
+
+	case BackpressureDropOldest:
+		for {
+			select {
+			case sub.ch <- v:
+				return
+			default:
+			}
+			select {
+			case <-sub.ch:
+				atomic.AddUint64(&sub.dropped, 1)
+			default:
+			}
+		}
+
+	case BackpressureConflate:
+		if p, ok := sub.pending[key]; ok {
+			for k, fv := range rec {
+				p[k] = fv
+			}
+			atomic.AddUint64(&sub.dropped, 1)
+		} else {
+			sub.pending[key] = copyRecord(rec)
+			sub.order = append(sub.order, key)
+		}
+		select {
+		case sub.wake <- struct{}{}:
+		default:
+		}
+
+	default:
+		select {
+		case sub.ch <- v:
+		case <-sub.done:
+		}
+	}
+}
+
+// trySend is used for the initial snapshots, it must not block since the consumer didn't get the subscription yet.
+func (sub *Subscription) trySend(key string, v Any, rec map[string]interface{}) {
+	if sub.policy != BackpressureBlock {
+		sub.send(key, v, rec)
+		return
+	}
+
+	sub.mux.Lock()
+	defer sub.mux.Unlock()
+	if sub.closed {
+		return
+	}
+	select {
+	case sub.ch <- v:
+	default:
+		atomic.AddUint64(&sub.dropped, 1)
+	}
+}
+
+// pump moves the conflated records to the channel in the order their keys were first updated.
+func (sub *Subscription) pump() {
+	defer close(sub.pumped)
+	for {
+		select {
+		case <-sub.wake:
+		case <-sub.done:
+			return
+		}
+
+		for {
+			sub.mux.Lock()
+			if len(sub.order) == 0 {
+				sub.mux.Unlock()
+				break
+			}
+			key := sub.order[0]
+			sub.order = sub.order[1:]
+			rec := sub.pending[key]
+			delete(sub.pending, key)
+			sub.mux.Unlock()
+
+			select {
+			case sub.ch <- anyx.Value(rec):
+			case <-sub.done:
+				return
+			}
+		}
+	}
+}
+
+// forward calls fn for every message received by sub in a new goroutine and calls closeFn once sub is closed,
+// it is used to build the typed subscriptions. When the streamer stops, fn gets everything that was buffered,
+// sends from fn should only give up on sub.cancel.
+func (sub *Subscription) forward(fn func(v Any), closeFn func()) {
+	go func() {
+		defer closeFn()
+		for v := range sub.ch {
+			fn(v)
+		}
+	}()
+}
+
+// stop signals the senders blocked on a full channel to give up, it never blocks so it's safe to call
+// with s.subMux held.
+func (sub *Subscription) stop() {
+	sub.stopped.Do(func() { close(sub.done) })
+}
+
+// close stops sub and closes its channel, cancel also makes the typed subscriptions drop what's still buffered.
+func (sub *Subscription) close(cancel bool) {
+	if cancel {
+		sub.cancels.Do(func() { close(sub.cancel) })
+	}
+	sub.stop()
+	sub.once.Do(func() {
+		sub.mux.Lock()
+		sub.closed = true
+		sub.mux.Unlock()
+		if sub.pumped != nil {
+			<-sub.pumped
+		}
+		close(sub.ch)
+	})
+}
+
+// Subscribe returns a new subscription to the keys in params, if other subscriptions already cover
+// some of the keys, the new subscription will get their current snapshot and only the missing keys are added.
+func (s *Streamer) Subscribe(ctx context.Context, svc string, params StreamRequestParams) (*Subscription, error) {
+	return s.SubscribeWithOptions(ctx, svc, params, nil)
+}
+
+// SubscribeWithOptions is like Subscribe but allows setting the buffer size and backpressure policy,
+// nil opts uses the defaults.
+func (s *Streamer) SubscribeWithOptions(ctx context.Context, svc string, params StreamRequestParams, opts *SubscribeOptions) (*Subscription, error) {
+	keys := splitKeys(params.Keys)
+	if len(keys) == 0 {
+		return nil, ErrNoSymbols
+	}
+
+	var o SubscribeOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = defaultBufferSize
+	}
+
+	ch := make(chan Any, o.BufferSize)
+	sub := &Subscription{
+		C:      ch,
+		s:      s,
+		svc:    svc,
+		fields: params.Fields,
+		keys:   map[string]struct{}{},
+		ch:     ch,
+		done:   make(chan struct{}),
+		cancel: make(chan struct{}),
+		policy: o.Policy,
+		full:   o.FullRecords,
+	}
+
+	if o.Policy == BackpressureConflate {
+		sub.pending = map[string]map[string]interface{}{}
+		sub.wake = make(chan struct{}, 1)
+		sub.pumped = make(chan struct{})
+		go sub.pump()
+	}
+
+	if err := s.addKeys(ctx, sub, keys); err != nil {
+		sub.close(true)
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// Unsubscribe closes all the subscriptions for svc and runs the UNSUBS command for all their keys.
+func (s *Streamer) Unsubscribe(ctx context.Context, svc string) error {
+	s.cmdMux.Lock()
+	defer s.cmdMux.Unlock()
+
+	s.stopSubs(svc)
+
+	s.subMux.Lock()
+	ss := s.svcs[svc]
+	delete(s.svcs, svc)
+	if ss != nil {
+		for sub := range ss.subs {
+			sub.keys = map[string]struct{}{}
+		}
+	}
+	s.subMux.Unlock()
+
+	if ss == nil {
+		return nil
+	}
+
+	for sub := range ss.subs {
+		sub.close(true)
+	}
+	s.state.delete(svc)
+
+	return s.sendRequest(ctx, svc, "UNSUBS", StreamRequestParams{Keys: strings.Join(sortedKeys(ss.keys), ",")})
+}
+
+// Unsubcribe is an alias for Unsubscribe.
+//
+// Deprecated: use Unsubscribe.
+func (s *Streamer) Unsubcribe(ctx context.Context, svc string) error {
+	return s.Unsubscribe(ctx, svc)
+}
+
+func (s *Streamer) addKeys(ctx context.Context, sub *Subscription, keys []string) (err error) {
+	s.cmdMux.Lock()
+	defer s.cmdMux.Unlock()
+
+	s.subMux.Lock()
+	select {
+	case <-s.done:
+		s.subMux.Unlock()
+		return ErrStreamerClosed
+	default:
+	}
+	if s.svcs == nil {
+		s.svcs = map[string]*streamService{}
+	}
+
+	ss := s.svcs[sub.svc]
+	if ss == nil {
+		ss = &streamService{subs: map[*Subscription]struct{}{}, keys: map[string]int{}}
+		s.svcs[sub.svc] = ss
+	}
+
+	var (
+		fields     = mergeFields(ss.fields, sub.fields)
+		prevFields = ss.fields
+		added      []string
+		newKeys    []string
+	)
+
+	for _, k := range keys {
+		if _, ok := sub.keys[k]; ok {
+			continue
+		}
+		added = append(added, k)
+		if ss.keys[k] == 0 {
+			newKeys = append(newKeys, k)
+		}
+	}
+
+	var (
+		cmd     string
+		reqKeys []string
+	)
+
+	switch {
+	case len(ss.keys) > 0 && fields != ss.fields:
+		// SUBS replaces the whole subscription, so it has to include all the keys with the new fields
+		cmd, reqKeys = "SUBS", append(sortedKeys(ss.keys), newKeys...)
+	case len(ss.keys) == 0 && len(newKeys) > 0:
+		cmd, reqKeys = "SUBS", newKeys
+	case len(newKeys) > 0:
+		cmd, reqKeys = "ADD", newKeys
+	}
+
+	// register before sending the request so we don't miss the first message
+	ss.subs[sub] = struct{}{}
+	ss.fields = fields
+	for _, k := range added {
+		sub.keys[k] = struct{}{}
+		if ss.keys[k]++; ss.keys[k] > 1 {
+			if rec, ok := s.state.get(sub.svc, k); ok {
+				sub.trySend(k, anyx.Value(rec), rec)
+			}
+		}
+	}
+	s.subMux.Unlock()
+
+	if cmd == "" {
+		return
+	}
+
+	if err = s.sendRequest(ctx, sub.svc, cmd, StreamRequestParams{Keys: strings.Join(reqKeys, ","), Fields: fields}); err == nil {
+		return
+	}
+
+	s.subMux.Lock()
+	ss.fields = prevFields
+	s.unregister(ss, sub, added)
+	s.subMux.Unlock()
+	return
+}
+
+func (s *Streamer) removeKeys(ctx context.Context, sub *Subscription, keys []string) error {
+	s.cmdMux.Lock()
+	defer s.cmdMux.Unlock()
+
+	s.subMux.Lock()
+	ss := s.svcs[sub.svc]
+	if ss == nil {
+		s.subMux.Unlock()
+		return nil
+	}
+	gone := s.unregister(ss, sub, keys)
+	s.subMux.Unlock()
+
+	if len(gone) == 0 {
+		return nil
+	}
+
+	s.state.delete(sub.svc, gone...)
+	return s.sendRequest(ctx, sub.svc, "UNSUBS", StreamRequestParams{Keys: strings.Join(gone, ",")})
+}
+
+// unregister removes keys from sub and returns the keys no other subscription is using, s.subMux must be held.
+func (s *Streamer) unregister(ss *streamService, sub *Subscription, keys []string) (gone []string) {
+	for _, k := range keys {
+		if _, ok := sub.keys[k]; !ok {
+			continue
+		}
+		delete(sub.keys, k)
+		if ss.keys[k]--; ss.keys[k] <= 0 {
+			delete(ss.keys, k)
+			gone = append(gone, k)
+		}
+	}
+
+	if len(sub.keys) == 0 {
+		delete(ss.subs, sub)
+	}
+
+	if len(ss.subs) == 0 {
+		delete(s.svcs, sub.svc)
+	}
+	return
+}
+
+// dispatch delivers a content item to every subscription listening to its key.
+func (s *Streamer) dispatch(svc string, c Any) {
+	rec := anyToRecord(c)
+	full := s.state.merge(svc, rec)
+	key, _ := rec["key"].(string)
+
+	// send can block, so it must not run with subMux held or Unsubscribe could never close the subscription
+	var subs []*Subscription
+	s.subMux.RLock()
+	if ss := s.svcs[svc]; ss != nil {
+		for sub := range ss.subs {
+			if _, ok := sub.keys[key]; ok {
+				subs = append(subs, sub)
+			}
+		}
+	}
+	s.subMux.RUnlock()
+
+	if len(subs) == 0 {
+		return
+	}
+
+	fullv := anyx.Value(full)
+	for _, sub := range subs {
+		if sub.full {
+			sub.send(key, fullv, full)
+		} else {
+			sub.send(key, c, rec)
+		}
+	}
+}
+
+// stopSubs signals the subscriptions of the given services (all of them if none) to stop blocking
+// before their removal takes subMux.
+func (s *Streamer) stopSubs(svcs ...string) {
+	s.subMux.RLock()
+	defer s.subMux.RUnlock()
+	for name, ss := range s.svcs {
+		if len(svcs) > 0 && !containsString(svcs, name) {
+			continue
+		}
+		for sub := range ss.subs {
+			sub.stop()
+		}
+	}
+}
+
+func splitKeys(keys string) (out []string) {
+	seen := map[string]bool{}
+	for _, k := range strings.Split(keys, ",") {
+		if k = strings.TrimSpace(k); k != "" && !seen[k] {
+			seen[k] = true
+			out = append(out, k)
+		}
+	}
+	return
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mergeFields returns the numerically sorted union of two comma separated field lists.
+func mergeFields(a, b string) string {
+	seen := map[int]bool{}
+	var fields []int
+	for _, f := range strings.Split(a+","+b, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil || seen[n] {
+			continue
+		}
+		seen[n] = true
+		fields = append(fields, n)
+	}
+	sort.Ints(fields)
+
+	out := make([]string, len(fields))
+	for i, n := range fields {
+		out[i] = strconv.Itoa(n)
+	}
+	return strings.Join(out, ",")
+}
@@ -0,0 +1,505 @@
+// Package tdtest provides a fake TD Ameritrade API server to test td.Streamer without the live endpoints.
+package tdtest // import "go.oneofone.dev/td/tdtest"
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.oneofone.dev/td"
+	"golang.org/x/oauth2"
+)
+
+// Streamer response codes.
+const (
+	CodeSuccess     = 0
+	CodeLoginDenied = 3
+	CodeBadCommand  = 21
+)
+
+// Request is a streamer request received by the server.
+type Request struct {
+	Service    string                 `json:"service"`
+	RequestID  string                 `json:"requestid"`
+	Command    string                 `json:"command"`
+	Account    string                 `json:"account"`
+	Source     string                 `json:"source"`
+	Parameters map[string]interface{} `json:"parameters"`
+}
+
+func (r *Request) param(k string) string {
+	v, _ := r.Parameters[k].(string)
+	return v
+}
+
+// Content is a single data item pushed to the clients, the "key" field is matched against the subscribed keys.
+type Content = map[string]interface{}
+
+// Server is a fake API that serves the userprincipals and quotes endpoints and a streamer websocket
+// implementing ADMIN LOGIN/LOGOUT/QOS, SUBS/ADD/UNSUBS and GET through OnGet.
+type Server struct {
+	*httptest.Server
+
+	// Principal is returned by the userprincipals endpoint, its StreamerSocketUrl points to this server.
+	Principal *td.UserPrincipal
+
+	// OnRequest is called for every streamer request, returning a non-zero code fails the request.
+	OnRequest func(req *Request) (code int, msg string)
+
+	// OnGet answers GET requests (for example CHART_HISTORY_FUTURES) with a snapshot, field 0 is set to the request id.
+	// Returning nil or leaving it unset fails the request.
+	OnGet func(req *Request) Content
+
+	// OnQuotes is called for every quotes request, returning a status other than 0 or 200 fails the request.
+	OnQuotes func(symbols []string) (status int)
+
+	mux       sync.Mutex
+	conns     map[*conn]struct{}
+	scripts   map[string][]Content
+	reqs      []*Request
+	logins    int
+	quotes    map[string]json.RawMessage
+	quoteReqs [][]string
+}
+
+type conn struct {
+	ws       *websocket.Conn
+	wmux     sync.Mutex
+	loggedIn bool
+	subs     map[string]*subscription // guarded by Server.mux
+}
+
+type subscription struct {
+	keys   map[string]bool
+	fields string
+}
+
+// NewServer starts and returns a new server, call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		conns:   map[*conn]struct{}{},
+		scripts: map[string][]Content{},
+		quotes:  map[string]json.RawMessage{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/userprincipals", s.serveUserPrincipals)
+	mux.HandleFunc("/v1/marketdata/quotes", s.serveQuotes)
+	mux.HandleFunc("/ws", s.serveWS)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the symbol is path escaped (/ES is %2FES), ServeMux would clean and redirect the decoded path
+		if sym, ok := quotePathSymbol(r.URL.EscapedPath()); ok {
+			s.writeQuotes(w, []string{sym})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+
+	s.Principal = &td.UserPrincipal{
+		UserID:           "test",
+		PrimaryAccountID: "123456789",
+		Accounts: []*td.Accounts{{
+			AccountID:         "123456789",
+			Company:           "AMER",
+			Segment:           "AMER",
+			AccountCdDomainID: "A000000012345678",
+			Authorizations: &td.Authorizations{
+				StreamerAccess: true,
+				LevelTwoQuotes: true,
+				StreamingNews:  true,
+			},
+		}},
+		StreamerInfo: &td.StreamerInfo{
+			AccessLevel:       "ACCT",
+			Acl:               "TESTACL",
+			AppID:             "test",
+			StreamerSocketUrl: "ws" + strings.TrimPrefix(s.URL, "http") + "/ws",
+			Token:             "test-token",
+			TokenTimestamp:    td.DateTime(strconv.Quote(time.Now().UTC().Format(td.DateTimeFormat))),
+			UserGroup:         "ACCT",
+		},
+		StreamerSubscriptionKeys: &td.StreamerSubscriptionKeys{
+			Keys: []*td.Keys{{Key: "test-subscription-key"}},
+		},
+	}
+
+	return s
+}
+
+// Client returns a td.Client pointed to the server.
+func (s *Server) Client(ctx context.Context) (*td.Client, error) {
+	c, err := td.New(ctx, "test", &oauth2.Token{AccessToken: "test", TokenType: "Bearer"}, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.BaseURL = s.URL + "/v1/"
+	return c, nil
+}
+
+// Script queues content for service, it is pushed to the clients as soon as they subscribe to the matching keys.
+func (s *Server) Script(service string, content ...Content) {
+	s.mux.Lock()
+	s.scripts[service] = append(s.scripts[service], content...)
+	s.mux.Unlock()
+}
+
+// Push sends content for service to every client subscribed to the matching keys.
+func (s *Server) Push(service string, content ...Content) {
+	s.mux.Lock()
+	type push struct {
+		c       *conn
+		content []Content
+	}
+	var pushes []push
+	for c := range s.conns {
+		if cs := c.filter(service, content, nil); len(cs) > 0 {
+			pushes = append(pushes, push{c, cs})
+		}
+	}
+	s.mux.Unlock()
+
+	for _, p := range pushes {
+		p.c.writeData(service, p.content)
+	}
+}
+
+// SetQuote sets the quote returned for symbol, q is encoded as json.
+func (s *Server) SetQuote(symbol string, q interface{}) error {
+	b, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+	s.mux.Lock()
+	s.quotes[symbol] = b
+	s.mux.Unlock()
+	return nil
+}
+
+// QuoteRequests returns the symbols of every quotes request received so far.
+func (s *Server) QuoteRequests() [][]string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return append([][]string(nil), s.quoteReqs...)
+}
+
+// Heartbeat sends a heartbeat notification to all the clients.
+func (s *Server) Heartbeat() {
+	msg := map[string]interface{}{
+		"notify": []map[string]string{{"heartbeat": strconv.FormatInt(nowMS(), 10)}},
+	}
+	for _, c := range s.connList() {
+		c.write(msg)
+	}
+}
+
+// DropConnections closes all the websocket connections without a logout, to simulate a network failure.
+func (s *Server) DropConnections() {
+	for _, c := range s.connList() {
+		c.ws.Close()
+	}
+}
+
+// Subscribed returns the sorted keys and the fields of service the clients are subscribed to.
+func (s *Server) Subscribed(service string) (keys []string, fields string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	seen := map[string]bool{}
+	for c := range s.conns {
+		sub := c.subs[service]
+		if sub == nil {
+			continue
+		}
+		fields = sub.fields
+		for k := range sub.keys {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return
+}
+
+// Requests returns all the streamer requests received so far.
+func (s *Server) Requests() []*Request {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return append([]*Request(nil), s.reqs...)
+}
+
+// Logins returns the number of successful logins.
+func (s *Server) Logins() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.logins
+}
+
+func (s *Server) connList() (out []*conn) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for c := range s.conns {
+		out = append(out, c)
+	}
+	return
+}
+
+func (s *Server) serveUserPrincipals(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	json.NewEncoder(w).Encode(s.Principal)
+}
+
+func (s *Server) serveQuotes(w http.ResponseWriter, r *http.Request) {
+	s.writeQuotes(w, splitKeys(r.URL.Query().Get("symbol")))
+}
+
+// quotePathSymbol returns the unescaped symbol of the single quote endpoint, /v1/marketdata/{symbol}/quotes.
+func quotePathSymbol(path string) (string, bool) {
+	const prefix, suffix = "/v1/marketdata/", "/quotes"
+	if !strings.HasPrefix(path, prefix) || !strings.HasSuffix(path, suffix) || len(path) <= len(prefix)+len(suffix) {
+		return "", false
+	}
+	esc := path[len(prefix) : len(path)-len(suffix)]
+	if strings.Contains(esc, "/") {
+		return "", false
+	}
+	sym, err := url.PathUnescape(esc)
+	return sym, err == nil
+}
+
+func (s *Server) writeQuotes(w http.ResponseWriter, symbols []string) {
+	s.mux.Lock()
+	s.quoteReqs = append(s.quoteReqs, symbols)
+	onQuotes := s.OnQuotes
+	s.mux.Unlock()
+
+	if onQuotes != nil {
+		if status := onQuotes(symbols); status != 0 && status != http.StatusOK {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+	}
+
+	out := map[string]json.RawMessage{}
+	s.mux.Lock()
+	for _, sym := range symbols {
+		if q, ok := s.quotes[sym]; ok {
+			out[sym] = q
+		}
+	}
+	s.mux.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+var upgrader = websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+
+func (s *Server) serveWS(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &conn{ws: ws, subs: map[string]*subscription{}}
+	s.mux.Lock()
+	s.conns[c] = struct{}{}
+	s.mux.Unlock()
+
+	defer func() {
+		s.mux.Lock()
+		delete(s.conns, c)
+		s.mux.Unlock()
+		ws.Close()
+	}()
+
+	for {
+		var reqs struct {
+			Requests []*Request `json:"requests"`
+		}
+		if err := ws.ReadJSON(&reqs); err != nil {
+			return
+		}
+		for _, req := range reqs.Requests {
+			if !s.handle(c, req) {
+				return
+			}
+		}
+	}
+}
+
+// handle processes a single request, it returns false if the connection should be closed.
+func (s *Server) handle(c *conn, req *Request) bool {
+	s.mux.Lock()
+	s.reqs = append(s.reqs, req)
+	onRequest, onGet := s.OnRequest, s.OnGet
+	s.mux.Unlock()
+
+	if onRequest != nil {
+		if code, msg := onRequest(req); code != CodeSuccess {
+			c.writeResponse(req, code, msg)
+			return true
+		}
+	}
+
+	var snapshot Content
+	if req.Command == "GET" && onGet != nil {
+		snapshot = onGet(req)
+	}
+
+	var added []string
+
+	s.mux.Lock()
+	code, msg := CodeSuccess, req.Service+" command "+req.Command+" succeeded"
+	switch {
+	case req.Service == "ADMIN" && req.Command == "LOGIN":
+		if req.param("token") != s.Principal.StreamerInfo.Token {
+			code, msg = CodeLoginDenied, "Login denied"
+			break
+		}
+		c.loggedIn = true
+		s.logins++
+
+	case req.Service == "ADMIN" && (req.Command == "LOGOUT" || req.Command == "QOS"):
+
+	case !c.loggedIn:
+		code, msg = CodeLoginDenied, "Not logged in"
+
+	case req.Command == "SUBS" || req.Command == "ADD":
+		sub := c.subs[req.Service]
+		if sub == nil || req.Command == "SUBS" {
+			sub = &subscription{keys: map[string]bool{}}
+			c.subs[req.Service] = sub
+		}
+		if f := req.param("fields"); f != "" {
+			sub.fields = f
+		}
+		for _, k := range splitKeys(req.param("keys")) {
+			if !sub.keys[k] {
+				sub.keys[k] = true
+				added = append(added, k)
+			}
+		}
+
+	case req.Command == "GET" && snapshot != nil:
+		snapshot["0"] = req.RequestID
+
+	case req.Command == "UNSUBS":
+		keys := splitKeys(req.param("keys"))
+		if sub := c.subs[req.Service]; sub != nil && len(keys) > 0 {
+			for _, k := range keys {
+				delete(sub.keys, k)
+			}
+		} else {
+			delete(c.subs, req.Service)
+		}
+
+	default:
+		code, msg = CodeBadCommand, "Bad command formatting"
+	}
+
+	var scripted []Content
+	if len(added) > 0 {
+		scripted = c.filter(req.Service, s.scripts[req.Service], added)
+	}
+	s.mux.Unlock()
+
+	c.writeResponse(req, code, msg)
+	if len(scripted) > 0 {
+		c.writeData(req.Service, scripted)
+	}
+	if code == CodeSuccess && snapshot != nil {
+		c.writeSnapshot(req, snapshot)
+	}
+
+	return !(req.Service == "ADMIN" && req.Command == "LOGOUT")
+}
+
+// filter returns the content items matching the subscribed keys of service, limited to only if it's not nil.
+// Server.mux must be held.
+func (c *conn) filter(service string, content []Content, only []string) (out []Content) {
+	sub := c.subs[service]
+	if sub == nil {
+		return
+	}
+	for _, ct := range content {
+		key, _ := ct["key"].(string)
+		if !sub.keys[key] || (only != nil && !contains(only, key)) {
+			continue
+		}
+		out = append(out, ct)
+	}
+	return
+}
+
+func (c *conn) writeResponse(req *Request, code int, msg string) {
+	c.write(map[string]interface{}{
+		"response": []map[string]interface{}{{
+			"service":   req.Service,
+			"requestid": req.RequestID,
+			"command":   req.Command,
+			"timestamp": nowMS(),
+			"content":   map[string]interface{}{"code": code, "msg": msg},
+		}},
+	})
+}
+
+func (c *conn) writeData(service string, content []Content) {
+	c.write(map[string]interface{}{
+		"data": []map[string]interface{}{{
+			"service":   service,
+			"timestamp": nowMS(),
+			"command":   "SUBS",
+			"content":   content,
+		}},
+	})
+}
+
+func (c *conn) writeSnapshot(req *Request, content Content) {
+	c.write(map[string]interface{}{
+		"snapshot": []map[string]interface{}{{
+			"service":   req.Service,
+			"timestamp": nowMS(),
+			"command":   req.Command,
+			"content":   []Content{content},
+		}},
+	})
+}
+
+func (c *conn) write(v interface{}) {
+	c.wmux.Lock()
+	c.ws.WriteJSON(v)
+	c.wmux.Unlock()
+}
+
+func splitKeys(s string) (out []string) {
+	for _, k := range strings.Split(s, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			out = append(out, k)
+		}
+	}
+	return
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func nowMS() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
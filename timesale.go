@@ -0,0 +1,102 @@
+package td
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+type TimeSaleType string
+
+const (
+	EquityTimeSale  TimeSaleType = "TIMESALE_EQUITY"
+	OptionsTimeSale TimeSaleType = "TIMESALE_OPTIONS"
+	FuturesTimeSale TimeSaleType = "TIMESALE_FUTURES"
+	ForexTimeSale   TimeSaleType = "TIMESALE_FOREX"
+)
+
+// Trade is a single time and sales print.
+type Trade struct {
+	Symbol   string    `json:"symbol,omitempty"`
+	Time     time.Time `json:"time,omitempty"`
+	Price    float64   `json:"price,omitempty"`
+	Size     float64   `json:"size,omitempty"`
+	Sequence int64     `json:"sequence,omitempty"`
+
+	// Gap is the number of trades missed since the previous trade of the same symbol, based on the sequence numbers.
+	Gap int64 `json:"gap,omitempty"`
+}
+
+type rawTrade struct {
+	Symbol   string  `json:"key"`
+	Time     int64   `json:"1"`
+	Price    float64 `json:"2"`
+	Size     float64 `json:"3"`
+	Sequence int64   `json:"4"`
+}
+
+// ParseTrade decodes a raw TIMESALE_* message.
+func ParseTrade(v Any) (*Trade, error) {
+	var rt rawTrade
+	if err := json.Unmarshal(marshalAny(v), &rt); err != nil {
+		return nil, err
+	}
+	return &Trade{
+		Symbol:   rt.Symbol,
+		Time:     time.Unix(0, rt.Time*int64(time.Millisecond)),
+		Price:    rt.Price,
+		Size:     rt.Size,
+		Sequence: rt.Sequence,
+	}, nil
+}
+
+// TimeSaleSubscription delivers typed trades and tracks the sequence numbers of every symbol to detect gaps.
+type TimeSaleSubscription struct {
+	*Subscription
+
+	// C shadows Subscription.C, reading from the raw channel will skip the gap detection.
+	C <-chan *Trade
+
+	last map[string]int64 // only accessed by the forwarding goroutine
+}
+
+func (ts *TimeSaleSubscription) checkGap(t *Trade) {
+	if t.Sequence == 0 {
+		return
+	}
+	if last, ok := ts.last[t.Symbol]; ok && t.Sequence > last+1 {
+		t.Gap = t.Sequence - last - 1
+	}
+	if t.Sequence > ts.last[t.Symbol] {
+		ts.last[t.Symbol] = t.Sequence
+	}
+}
+
+// TimeSales subscribes to the time and sales of the given symbols.
+func (s *Streamer) TimeSales(ctx context.Context, typ TimeSaleType, symbols ...string) (*TimeSaleSubscription, error) {
+	const allFields = "0,1,2,3,4"
+	sub, err := s.Subscribe(ctx, string(typ), StreamRequestParams{
+		Keys:   strings.Join(symbols, ","),
+		Fields: allFields,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *Trade, cap(sub.ch))
+	ts := &TimeSaleSubscription{Subscription: sub, C: ch, last: map[string]int64{}}
+	sub.forward(func(v Any) {
+		t, err := ParseTrade(v)
+		if err != nil || t.Symbol == "" {
+			return
+		}
+		ts.checkGap(t)
+		select {
+		case ch <- t:
+		case <-sub.cancel:
+		}
+	}, func() { close(ch) })
+
+	return ts, nil
+}
@@ -0,0 +1,36 @@
+package td
+
+import "testing"
+
+func TestTimeSaleGaps(t *testing.T) {
+	tr, err := ParseTrade(rawAny(t, `{"key":"AAPL","1":1597411800000,"2":100.5,"3":200,"4":10}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.Symbol != "AAPL" || tr.Time.Unix() != 1597411800 || tr.Price != 100.5 || tr.Size != 200 || tr.Sequence != 10 {
+		t.Fatalf("unexpected trade: %+v", tr)
+	}
+
+	ts := &TimeSaleSubscription{last: map[string]int64{}}
+	tests := []struct {
+		symbol string
+		seq    int64
+		gap    int64
+	}{
+		{"AAPL", 10, 0},
+		{"AAPL", 11, 0},
+		{"AAPL", 14, 2},
+		{"MSFT", 100, 0}, // every symbol has its own sequence
+		{"AAPL", 12, 0},  // late trades don't move the sequence back
+		{"AAPL", 15, 0},
+		{"AAPL", 0, 0}, // no sequence
+		{"MSFT", 105, 4},
+	}
+	for i, tc := range tests {
+		tr := &Trade{Symbol: tc.symbol, Sequence: tc.seq}
+		ts.checkGap(tr)
+		if tr.Gap != tc.gap {
+			t.Fatalf("%d: expected a gap of %d, got %d", i, tc.gap, tr.Gap)
+		}
+	}
+}
@@ -24,6 +24,10 @@ const (
 	AssetTypeCashEquivalent AssetType = "CASH_EQUIVALENT"
 	AssetTypeFixedIncome    AssetType = "FIXED_INCOME"
 	AssetTypeCurrency       AssetType = "CURRENCY"
+	AssetTypeETF            AssetType = "ETF"
+	AssetTypeFuture         AssetType = "FUTURE"
+	AssetTypeFutureOption   AssetType = "FUTURE_OPTION"
+	AssetTypeForex          AssetType = "FOREX"
 )
 
 type Session string
@@ -758,103 +762,6 @@ type Preferences struct {
 	OptionTaxLotMethod               OptionTaxLotMethod               `json:"optionTaxLotMethod,omitempty"`
 }
 
-type Quote struct {
-	AskID                        string  `json:"askId,omitempty"`
-	AskPrice                     float64 `json:"askPrice,omitempty"`
-	AskPriceInDouble             float64 `json:"askPriceInDouble,omitempty"`
-	AskSize                      int64   `json:"askSize,omitempty"`
-	BidID                        string  `json:"bidId,omitempty"`
-	BidPrice                     float64 `json:"bidPrice,omitempty"`
-	BidPriceInDouble             float64 `json:"bidPriceInDouble,omitempty"`
-	BidSize                      int64   `json:"bidSize,omitempty"`
-	ChangeInDouble               float64 `json:"changeInDouble,omitempty"`
-	ClosePrice                   float64 `json:"closePrice,omitempty"`
-	ClosePriceInDouble           float64 `json:"closePriceInDouble,omitempty"`
-	ContractType                 string  `json:"contractType,omitempty"`
-	Deliverables                 string  `json:"deliverables,omitempty"`
-	Delta                        float64 `json:"delta,omitempty"`
-	DeltaInDouble                float64 `json:"deltaInDouble,omitempty"`
-	Description                  string  `json:"description,omitempty"`
-	Digits                       int64   `json:"digits,omitempty"`
-	DivAmount                    float64 `json:"divAmount,omitempty"`
-	DivDate                      string  `json:"divDate,omitempty"`
-	DivYield                     float64 `json:"divYield,omitempty"`
-	Exchange                     string  `json:"exchange,omitempty"`
-	ExchangeName                 string  `json:"exchangeName,omitempty"`
-	ExerciseType                 string  `json:"exerciseType,omitempty"`
-	ExpirationType               string  `json:"expirationType,omitempty"`
-	FiftyTwoWkHigh               float64 `json:"52WkHigh,omitempty"`
-	FiftyTwoWkHighInDouble       float64 `json:"52WkHighInDouble,omitempty"`
-	FiftyTwoWkLow                float64 `json:"52WkLow,omitempty"`
-	FiftyTwoWkLowInDouble        float64 `json:"52WkLowInDouble,omitempty"`
-	FutureActiveSymbol           string  `json:"futureActiveSymbol,omitempty"`
-	FutureExpirationDate         string  `json:"futureExpirationDate,omitempty"`
-	FutureIsActive               bool    `json:"futureIsActive,omitempty"`
-	FutureIsTradable             bool    `json:"futureIsTradable,omitempty"`
-	FutureMultiplier             float64 `json:"futureMultiplier,omitempty"`
-	FuturePercentChange          float64 `json:"futurePercentChange,omitempty"`
-	FuturePriceFormat            string  `json:"futurePriceFormat,omitempty"`
-	FutureSettlementPrice        float64 `json:"futureSettlementPrice,omitempty"`
-	FutureTradingHours           string  `json:"futureTradingHours,omitempty"`
-	Gamma                        float64 `json:"gamma,omitempty"`
-	GammaInDouble                float64 `json:"gammaInDouble,omitempty"`
-	HighPrice                    float64 `json:"highPrice,omitempty"`
-	HighPriceInDouble            float64 `json:"highPriceInDouble,omitempty"`
-	InTheMoney                   bool    `json:"inTheMoney,omitempty"`
-	IsTradable                   bool    `json:"isTradable,omitempty"`
-	LastID                       string  `json:"lastId,omitempty"`
-	LastPrice                    float64 `json:"lastPrice,omitempty"`
-	LastPriceInDouble            float64 `json:"lastPriceInDouble,omitempty"`
-	LastSize                     int64   `json:"lastSize,omitempty"`
-	LowPrice                     float64 `json:"lowPrice,omitempty"`
-	LowPriceInDouble             float64 `json:"lowPriceInDouble,omitempty"`
-	Marginable                   bool    `json:"marginable,omitempty"`
-	Mark                         float64 `json:"mark,omitempty"`
-	MarketMaker                  string  `json:"marketMaker,omitempty"`
-	MoneyIntrinsicValue          float64 `json:"moneyIntrinsicValue,omitempty"`
-	MoneyIntrinsicValueInDouble  float64 `json:"moneyIntrinsicValueInDouble,omitempty"`
-	Multiplier                   float64 `json:"multiplier,omitempty"`
-	MultiplierInDouble           float64 `json:"multiplierInDouble,omitempty"`
-	NAV                          float64 `json:"nAV,omitempty"`
-	NetChange                    float64 `json:"netChange,omitempty"`
-	NetChangeInDouble            float64 `json:"netChangeInDouble,omitempty"`
-	OpenInterest                 float64 `json:"openInterest,omitempty"`
-	OpenPrice                    float64 `json:"openPrice,omitempty"`
-	OpenPriceInDouble            float64 `json:"openPriceInDouble,omitempty"`
-	PeRatio                      float64 `json:"peRatio,omitempty"`
-	PercentChange                float64 `json:"percentChange,omitempty"`
-	Product                      string  `json:"product,omitempty"`
-	QuoteTimeInLong              int64   `json:"quoteTimeInLong,omitempty"`
-	RegularMarketLastPrice       float64 `json:"regularMarketLastPrice,omitempty"`
-	RegularMarketLastSize        int64   `json:"regularMarketLastSize,omitempty"`
-	RegularMarketNetChange       float64 `json:"regularMarketNetChange,omitempty"`
-	RegularMarketTradeTimeInLong int64   `json:"regularMarketTradeTimeInLong,omitempty"`
-	Rho                          float64 `json:"rho,omitempty"`
-	RhoInDouble                  float64 `json:"rhoInDouble,omitempty"`
-	SecurityStatus               string  `json:"securityStatus,omitempty"`
-	SettlementType               string  `json:"settlementType,omitempty"`
-	Shortable                    bool    `json:"shortable,omitempty"`
-	StrikePrice                  float64 `json:"strikePrice,omitempty"`
-	StrikePriceInDouble          float64 `json:"strikePriceInDouble,omitempty"`
-	Symbol                       string  `json:"symbol,omitempty"`
-	TheoreticalOptionValue       float64 `json:"theoreticalOptionValue,omitempty"`
-	Theta                        float64 `json:"theta,omitempty"`
-	ThetaInDouble                float64 `json:"thetaInDouble,omitempty"`
-	Tick                         float64 `json:"tick,omitempty"`
-	TickAmount                   float64 `json:"tickAmount,omitempty"`
-	TimeValue                    float64 `json:"timeValue,omitempty"`
-	TimeValueInDouble            float64 `json:"timeValueInDouble,omitempty"`
-	TotalVolume                  int64   `json:"totalVolume,omitempty"`
-	TradeTimeInLong              int64   `json:"tradeTimeInLong,omitempty"`
-	TradingHours                 string  `json:"tradingHours,omitempty"`
-	Underlying                   string  `json:"underlying,omitempty"`
-	UnderlyingPrice              float64 `json:"underlyingPrice,omitempty"`
-	UvExpirationType             string  `json:"uvExpirationType,omitempty"`
-	Vega                         float64 `json:"vega,omitempty"`
-	VegaInDouble                 float64 `json:"vegaInDouble,omitempty"`
-	Volatility                   float64 `json:"volatility,omitempty"`
-}
-
 type TransactionItem struct {
 	AccountID            int64          `json:"accountId,omitempty"`
 	Amount               float64        `json:"amount,omitempty"`